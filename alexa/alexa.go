@@ -2,7 +2,6 @@ package alexa
 
 import (
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/kpango/glg"
@@ -10,14 +9,61 @@ import (
 	"github.com/rking788/twitch-box/twitch"
 )
 
+var (
+	twitchClient *twitch.Client
+	history      twitch.HistoryStore
+	queues       twitch.QueueStore
+	recorder     ListeningRecorder
+	streamTokens *StreamTokenSigner
+
+	askWhichStream   bool
+	browseTopStreams bool
+)
+
+// Env is the environment specific configuration used by the package.
+type Env struct {
+	// Client is used for all requests made to the Twitch APIs.
+	Client *twitch.Client
+	// History keeps track of the streams each user has played.
+	History twitch.HistoryStore
+	// Queues keep each user's place in the live streams for the next and previous commands.
+	Queues twitch.QueueStore
+	// Recorder is optional, if it is nil the streams played are only logged.
+	Recorder ListeningRecorder
+	// StreamTokens signs the tokens sent with each stream. If nil, a signer with a random
+	// key is used.
+	StreamTokens *StreamTokenSigner
+	// AskWhichStream will ask the user which stream to play when they ask to play a stream
+	// and more than one of their followed channels is live, instead of playing the first one.
+	AskWhichStream bool
+	// BrowseTopStreams will offer to play the most watched live streams on Twitch when none
+	// of the user's followed channels are live.
+	BrowseTopStreams bool
+}
+
+// InitEnv provides a package level initialization point for any work that is environment specific.
+func InitEnv(env Env) {
+	twitchClient = env.Client
+	history = env.History
+	queues = env.Queues
+	recorder = env.Recorder
+	streamTokens = env.StreamTokens
+	askWhichStream = env.AskWhichStream
+	browseTopStreams = env.BrowseTopStreams
+	if streamTokens == nil {
+		streamTokens = NewStreamTokenSigner(nil)
+	}
+}
+
 // WelcomePrompt is responsible for returning a prompt to the user when launching the skill
-func WelcomePrompt(echoRequest *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+func WelcomePrompt(request *Request) (response *skillserver.EchoResponse) {
 
 	response = skillserver.NewEchoResponse()
 	flag := false
 	response.OutputSpeech("Welcome, would you like to start playing one of you followed streams?").
 		Reprompt("Should I start playing a Twitch stream?").
 		EndSession(&flag)
+	askQuestion(response, welcomeQuestion)
 
 	return
 }
@@ -26,130 +72,281 @@ func WelcomePrompt(echoRequest *skillserver.EchoRequest) (response *skillserver.
 // account from the Alexa app. Then the user's followers will be requested and the audio will
 // be played for one of their followed channels. If the device the user is interacting with supports
 // video playback then a video stream will be returned.
-func StartAudioStream(echoRequest *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+func StartAudioStream(request *Request) (response *skillserver.EchoResponse) {
+
+	command := twitch.PLAY
+	switch request.GetIntentName() {
+	case "AMAZON.ResumeIntent":
+		command = twitch.RESUME
+	case "AMAZON.PreviousIntent":
+		command = twitch.PREVIOUS
+	case "AMAZON.NextIntent":
+		command = twitch.NEXT
+	case "AMAZON.PauseIntent":
+		command = twitch.PAUSE
+	}
+
+	streamRequest := newStreamRequest(request, command)
+	streamRequest.chooseStream = askWhichStream && command == twitch.PLAY
+
+	return startStream(streamRequest)
+}
+
+// ResumeAudioStream will resume the stream that was playing on the user's device. The
+// AudioPlayer state in the request has the token of the stream that was playing. If that
+// token is missing or invalid, the current stream in the user's queue is resumed instead.
+func ResumeAudioStream(request *Request) (response *skillserver.EchoResponse) {
+
+	streamRequest := newStreamRequest(request, twitch.RESUME)
+	streamRequest.resumeChannelID = resumeChannelID(request.Body)
+
+	return startStream(streamRequest)
+}
+
+// resumeChannelID will return the channel ID from the stream token in the AudioPlayer state of
+// the raw request body, or the empty string if there isn't a valid token.
+func resumeChannelID(body []byte) string {
+	state := ParseAudioPlayerState(body)
+	if state == nil || state.Token == "" {
+		return ""
+	}
+
+	token, err := streamTokens.Parse(state.Token)
+	if err != nil {
+		glg.Warnf("Ignoring AudioPlayer token while resuming: %s", err.Error())
+		return ""
+	}
+
+	return token.ChannelID
+}
+
+// streamRequest describes the stream to start for a request.
+type streamRequest struct {
+	accessToken string
+	command     twitch.PlaybackCommand
+	// resumeChannelID is the channel to resume if it is still live.
+	resumeChannelID string
+	supportsVideo   bool
+	// chooseStream will ask the user which stream to play if more than one is live.
+	chooseStream bool
+	// source is the category to play streams from, nil for the user's followed channels.
+	source *twitch.QueueSource
+	// keepSource continues with the source the user's queue is already using instead of
+	// the request's source, e.g. so next stays in the category the user chose.
+	keepSource bool
+	// language is the user's language, as a two letter ISO 639-1 code, empty if it isn't known.
+	language string
+	// offerBrowse will offer the most watched live streams if none of the user's followed
+	// channels are live.
+	offerBrowse bool
+}
+
+// newStreamRequest will create the stream request for the command from an intent request.
+func newStreamRequest(request *Request, command twitch.PlaybackCommand) *streamRequest {
+	supportedInterfaces := request.Context.System.Device.SupportedIntefaces
+
+	return &streamRequest{
+		accessToken:   request.Session.User.AccessToken,
+		command:       command,
+		supportsVideo: (supportedInterfaces["VideoPlayer"] != nil) || (supportedInterfaces["VideoApp"] != nil),
+		keepSource:    command != twitch.PLAY,
+		language:      localeLanguage(ParseLocale(request.Body)),
+		offerBrowse:   browseTopStreams,
+	}
+}
+
+// variantConstraints will return the constraints used to choose the stream variant. If the
+// device can play video, then play video; otherwise just play audio.
+func (request *streamRequest) variantConstraints() twitch.VariantConstraints {
+	if request.supportsVideo {
+		glg.Debug("Looking for video stream...")
+		return twitch.VariantConstraints{MaxHeight: 720, PreferHighFrameRate: true}
+	}
+
+	glg.Debug("Only supports audio playback...")
+	return twitch.VariantConstraints{AudioOnly: true}
+}
+
+// startStream will play the stream chosen for the request's command. If a resume channel ID
+// is provided and that channel is still live, it is made the current stream in the user's
+// queue before the command is applied.
+func startStream(request *streamRequest) (response *skillserver.EchoResponse) {
 
 	response = skillserver.NewEchoResponse()
-	accessToken := echoRequest.Session.User.AccessToken
+	accessToken := request.accessToken
 	if accessToken == "" {
-		response := skillserver.NewEchoResponse()
-		response.
-			OutputSpeech("Sorry, it looks like your Twitch account needs to be linked in " +
-				"the Alexa app.").
-			LinkAccountCard()
-		return response
+		return linkAccountResponse()
 	}
 
 	glg.Debugf("Loading user with access token: %s", accessToken)
 
-	client := &http.Client{}
-
-	// Use empty UID to get current user
-	user, err := twitch.GetUserByID(client, accessToken, "")
+	// Validating the token will find the current user and catch expired or revoked
+	// tokens before making any other requests.
+	tokenInfo, err := twitchClient.RequireToken(accessToken)
 	if err != nil {
-		fmt.Println("Error loading the current user: ", err.Error())
-		response.OutputSpeech("There was an error loading your Twitch account, please try again later.")
-		return
+		glg.Errorf("Error validating the user's access token: %s", err.Error())
+		return ErrorResponse(err)
 	}
+	user := tokenInfo.User()
 	glg.Debugf("Found user: %+v\n", user)
 
-	follows, err := twitch.GetFollows(client, user)
-	if err != nil {
-		fmt.Println("Error loading user's follows: ", err.Error())
-		response.OutputSpeech("Failed to load your follows from Twitch, please try again later")
-		return
+	source := request.source
+	if request.keepSource {
+		source = currentQueueSource(user)
 	}
 
-	followIDs := follows.FollowIDsList()
-
-	// Request all live streams based on all of the followed user_id values.
-	// This will return only live channels and the first ID of that set should be used in
-	// this next call.
-	liveStreams, err := twitch.FindLiveStreams(client, followIDs)
+	liveStreams, err := loadLiveStreams(accessToken, user, source)
+	if twitch.IsPartial(err) {
+		glg.Warnf("Only some of the followed channels could be checked: %s", err.Error())
+	} else if err != nil {
+		glg.Errorf("Error loading live streams: %s", err.Error())
+		return ErrorResponse(err)
+	}
 
-	if len(liveStreams.Data) <= 0 {
+	if len(liveStreams.Data) <= 0 && source != nil && source.Browse {
+		response.OutputSpeech("Sorry, I couldn't find any live streams right now")
+		return
+	} else if len(liveStreams.Data) <= 0 && source != nil {
+		response.OutputSpeech(fmt.Sprintf("Sorry, it looks like nobody is streaming %s right now", source.GameName))
+		return
+	} else if len(liveStreams.Data) <= 0 && request.offerBrowse {
+		return offerBrowseResponse(newBrowseOffer(user, request.language))
+	} else if len(liveStreams.Data) <= 0 {
 		response.OutputSpeech("Sorry, it looks like none of your followed channels are live right now")
 		return
 	}
 
-	command := twitch.PLAY
-	switch echoRequest.GetIntentName() {
-	case "AMAZON.ResumeIntent":
-		command = twitch.RESUME
-	case "AMAZON.PreviousIntent":
-		command = twitch.PREVIOUS
-	case "AMAZON.NextIntent":
-		command = twitch.NEXT
-	case "AMAZON.PauseIntent":
-		command = twitch.PAUSE
+	if request.chooseStream && source == nil && len(liveStreams.Data) > 1 {
+		return chooseStreamResponse(newStreamChoices(liveStreams.Data))
 	}
 
-	selectedStream := twitch.FindStreamForCommand(user, liveStreams.Data, command, response)
-	followedUser, err := twitch.GetUserByID(client, accessToken, selectedStream.UserID)
-	if err != nil {
-		fmt.Println("Error loading followed channel's user data: ", err.Error())
-		response.OutputSpeech("Failed to find a followed stream, please try again later")
+	changes := &twitch.QueueChanges{SetSource: !request.keepSource, Source: source,
+		JumpTo: request.resumeChannelID, CountGamePlay: true}
+	selectedStream := twitch.FindStreamForCommand(queues, user, liveStreams.Data, request.command, changes, response)
+	if selectedStream == nil {
 		return
 	}
 
-	glg.Debugf("Found followed user: %+v\n", followedUser)
+	return playStream(request, user, selectedStream, response)
+}
 
-	// If the device can play video, then play video; otherwise just play audio
-	streamQuality := "audio_only"
-	supportedInterfaces := echoRequest.Context.System.Device.SupportedIntefaces
-	supportsVideo := (supportedInterfaces["VideoPlayer"] != nil) || (supportedInterfaces["VideoApp"] != nil)
-	if supportsVideo {
-		glg.Debug("Looking for video stream...")
-		streamQuality = "720p"
-	} else {
-		glg.Debug("Only supports audio playback...")
-		streamQuality = "audio_only"
+// linkAccountResponse will ask the user to link their Twitch account in the Alexa app.
+func linkAccountResponse() *skillserver.EchoResponse {
+	response := skillserver.NewEchoResponse()
+	response.
+		OutputSpeech("Sorry, it looks like your Twitch account needs to be linked in " +
+			"the Alexa app.").
+		LinkAccountCard()
+
+	return response
+}
+
+// playStream will add the directive to play the live stream to the response, a video stream
+// is used if the request's device supports video playback. The stream is saved as the
+// user's current stream, it should already be the current stream in the user's queue.
+func playStream(request *streamRequest, user *twitch.User, selectedStream *twitch.Stream,
+	response *skillserver.EchoResponse) *skillserver.EchoResponse {
+
+	accessToken := request.accessToken
+	var err error
+	followedUser := &twitch.User{ID: selectedStream.UserID, Login: selectedStream.UserLogin,
+		DisplayName: selectedStream.UserName}
+	if followedUser.Login == "" {
+		// Streams from the legacy follows lookup don't include the user's login
+		followedUser, err = twitchClient.GetUserByID(accessToken, selectedStream.UserID)
+		if err != nil {
+			glg.Errorf("Error loading followed channel's user data: %s", err.Error())
+			return ErrorResponse(err)
+		}
 	}
 
-	streamVariant, err := twitch.GetStream(client, followedUser.Login, accessToken, streamQuality)
+	glg.Debugf("Found followed user: %+v\n", followedUser)
+
+	selection, err := twitchClient.GetStream(followedUser.Login, request.variantConstraints())
 	if err != nil {
-		fmt.Println("Error loading stream Variant: ", err.Error())
-		response.OutputSpeech("Failed to find a stream URL, please try again later")
-		return
+		glg.Errorf("Error loading stream Variant: %s", err.Error())
+		return ErrorResponse(err)
 	}
+	for _, reason := range selection.FallbackReasons {
+		glg.Infof("Using fallback stream variant for %s: %s", followedUser.Login, reason)
+	}
+	streamVariant := selection.Selected
 
 	glg.Debugf("Found stream URL: %s\n", streamVariant.URI)
 
 	response.OutputSpeech(fmt.Sprintf("Starting stream for %s", followedUser.DisplayName))
-	twitch.SaveUsersCurrentStream(user, selectedStream)
-	if streamVariant.Video == "audio_only" {
+	twitch.SaveUsersCurrentStream(history, user, selectedStream)
+	if streamVariant.AudioOnly {
 		glg.Debug("Sending Audio directive response")
 		// TODO: This should only create a card if they are starting a new stream,
 		// not resuming or skipping
-		widthReplaced := strings.Replace(selectedStream.ThumbnailURL, "{width}", "320", -1)
-		heightReplaced := strings.Replace(widthReplaced, "{height}", "180", -1)
-		response.AppendAudioDirective(NewAudioDirectiveWithStreamURL(streamVariant.URI))
-		glg.Debugf("Setting card thumbnail to be: %s", heightReplaced)
-		response.StandardCard(followedUser.DisplayName, selectedStream.Title, heightReplaced, heightReplaced)
+		thumbnail := thumbnailURL(selectedStream.ThumbnailURL)
+		token := streamTokens.Sign(NewStreamToken(followedUser.ID, streamVariant.Name))
+		response.AppendAudioDirective(NewAudioDirectiveWithStreamURL(streamVariant.URI, token))
+		glg.Debugf("Setting card thumbnail to be: %s", thumbnail)
+		response.StandardCard(followedUser.DisplayName, selectedStream.Title, thumbnail, thumbnail)
 	} else {
 		glg.Debug("Sending video directive response")
 		response.AppendVideoDirective(NewVideoDirectiveWithStreamURL(streamVariant.URI, selectedStream.Title, followedUser.DisplayName))
 	}
 
-	return
+	return response
+}
+
+// thumbnailURL will fill in the size of a Twitch thumbnail URL template. Stream thumbnails
+// use {width} and VOD thumbnails use %{width}.
+func thumbnailURL(template string) string {
+	replacer := strings.NewReplacer("%{width}", "320", "%{height}", "180", "{width}", "320", "{height}", "180")
+	return replacer.Replace(template)
+}
+
+// loadLiveStreams will load the live streams the user's queue moves through, either the
+// live streams for all of the user's followed channels in a single (paginated) call, the
+// most watched live streams or the live streams in the source's category.
+func loadLiveStreams(accessToken string, user *twitch.User, source *twitch.QueueSource) (*twitch.StreamsResponse, error) {
+	if source == nil {
+		return twitchClient.GetLiveFollows(accessToken, user)
+	} else if source.Browse {
+		return loadBrowseStreams(source)
+	}
+
+	return loadCategoryStreams(accessToken, user, source.GameID)
+}
+
+// currentQueueSource will return the source the user's queue is using, nil for the user's
+// followed channels. If the queue can't be loaded the followed channels are used.
+func currentQueueSource(user *twitch.User) *twitch.QueueSource {
+	queue, err := queues.Queue(user.ID)
+	if err != nil {
+		glg.Warnf("Failed to load the playback queue's source, using followed channels: %s", err.Error())
+		return nil
+	}
+
+	return queue.Source
+}
+
+// localeLanguage will return the language of the locale, e.g. "en" for "en-US".
+func localeLanguage(locale string) string {
+	return strings.ToLower(strings.SplitN(locale, "-", 2)[0])
 }
 
 // StartVideoStream currently just uses the audio stream method to start a video live stream
 // if video playback is supported, otherwise falls back to an audio only stream.
-func StartVideoStream(echoRequest *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+func StartVideoStream(request *Request) (response *skillserver.EchoResponse) {
 	// TODO: This should just use the same method as the audio stream, if video is possible
 	// it'll use that instead of just audio
-	return StartAudioStream(echoRequest)
+	return StartAudioStream(request)
 }
 
 // NewAudioDirectiveWithStreamURL will create a new AudioDirective that is initialized with the
-// provided URL.
-func NewAudioDirectiveWithStreamURL(url string) *skillserver.AudioDirective {
+// provided URL and stream token.
+func NewAudioDirectiveWithStreamURL(url, token string) *skillserver.AudioDirective {
 	return &skillserver.AudioDirective{
 		Type:         "AudioPlayer.Play",
 		PlayBehavior: "REPLACE_ALL",
 		AudioItem: &skillserver.AudioItem{
 			Stream: &skillserver.Stream{
-				Token:    "12345",
+				Token:    token,
 				URL:      url,
 				OffsetMS: 0,
 			},
@@ -157,6 +354,41 @@ func NewAudioDirectiveWithStreamURL(url string) *skillserver.AudioDirective {
 	}
 }
 
+// EnqueueAudioDirective is an AudioPlayer.Play directive that adds a stream to the end of the
+// device's queue. skillserver's AudioDirective doesn't support the expectedPreviousToken
+// that is required when enqueueing a stream.
+type EnqueueAudioDirective struct {
+	Type         string `json:"type"`
+	PlayBehavior string `json:"playBehavior"`
+	AudioItem    struct {
+		Stream struct {
+			Token                 string `json:"token"`
+			ExpectedPreviousToken string `json:"expectedPreviousToken"`
+			URL                   string `json:"url"`
+			OffsetMS              int    `json:"offsetInMilliseconds"`
+		} `json:"stream"`
+	} `json:"audioItem"`
+}
+
+// NewEnqueueAudioDirective will create a new directive that plays the stream URL after the
+// stream with the expected previous token finishes.
+func NewEnqueueAudioDirective(url, token, expectedPreviousToken string) *EnqueueAudioDirective {
+	directive := &EnqueueAudioDirective{
+		Type:         "AudioPlayer.Play",
+		PlayBehavior: "ENQUEUE",
+	}
+	directive.AudioItem.Stream.Token = token
+	directive.AudioItem.Stream.ExpectedPreviousToken = expectedPreviousToken
+	directive.AudioItem.Stream.URL = url
+
+	return directive
+}
+
+// appendDirective will add a directive that skillserver doesn't have a type for to the response.
+func appendDirective(response *skillserver.EchoResponse, directive interface{}) {
+	response.Response.Directives = append(response.Response.Directives, directive)
+}
+
 // NewVideoDirectiveWithStreamURL will construct and initialize a new video directive to be
 // returned the Alexa server.
 func NewVideoDirectiveWithStreamURL(url, title, subtitle string) *skillserver.VideoDirective {
@@ -0,0 +1,250 @@
+package alexa
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// testMasterPlaylist is a small master playlist in the format returned by usher.
+const testMasterPlaylist = `#EXTM3U
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="160p30",NAME="160p",AUTOSELECT=YES,DEFAULT=YES
+#EXT-X-STREAM-INF:PROGRAM-ID=1,BANDWIDTH=230000,RESOLUTION=284x160,CODECS="avc1.4D400C,mp4a.40.2",VIDEO="160p30"
+http://localhost/160p30.m3u8
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="audio_only",NAME="audio_only",AUTOSELECT=NO,DEFAULT=NO
+#EXT-X-STREAM-INF:PROGRAM-ID=1,BANDWIDTH=160000,CODECS="mp4a.40.2",VIDEO="audio_only"
+http://localhost/audio_only.m3u8
+`
+
+// testPlaybackTokens is a PlaybackTokenProvider that doesn't make any requests.
+type testPlaybackTokens struct{}
+
+func (testPlaybackTokens) LiveToken(channelLogin string) (*twitch.PlaybackAccessToken, error) {
+	return &twitch.PlaybackAccessToken{Value: "value", Signature: "sig"}, nil
+}
+
+func (testPlaybackTokens) VODToken(vodID string) (*twitch.PlaybackAccessToken, error) {
+	return &twitch.PlaybackAccessToken{Value: "value", Signature: "sig"}, nil
+}
+
+// listeningRecord is a single call to a testRecorder.
+type listeningRecord struct {
+	userID, streamUserID string
+	startedAt, endedAt   time.Time
+}
+
+// testRecorder is a ListeningRecorder that keeps every record in memory.
+type testRecorder struct {
+	records []listeningRecord
+}
+
+func (recorder *testRecorder) RecordListening(userID, streamUserID string, startedAt, endedAt time.Time) error {
+	recorder.records = append(recorder.records, listeningRecord{userID, streamUserID, startedAt, endedAt})
+	return nil
+}
+
+// testEnv is the package environment used by the handler tests. Every Twitch request is
+// answered by a stand-in server for the user "viewer" (ID 1000) who follows the live
+// channels "streamer" (ID 2000) and "other" (ID 3000), and the offline channel "sleepy"
+// (ID 4000) which has a past broadcast. The channel "stranger" (ID 5000) is live but not
+// followed, it can only be found with the channel search. Streamer and the unfollowed
+// "crafter" (ID 7000) are streaming Minecraft (game 100), Stranger is streaming Chess
+// (game 200) and nobody is streaming Tetris (game 300). Crafter and Stranger are the most
+// watched streams on Twitch, but nobody is streaming in German.
+type testEnv struct {
+	server   *httptest.Server
+	history  *twitch.MemoryHistoryStore
+	queues   *twitch.MemoryQueueStore
+	recorder *testRecorder
+	// followsOffline reports none of the followed channels as live.
+	followsOffline bool
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	env := &testEnv{
+		history:  twitch.NewMemoryHistoryStore(),
+		queues:   twitch.NewMemoryQueueStore(),
+		recorder: &testRecorder{},
+	}
+
+	startedAt := time.Now().Add(-2*time.Hour - 5*time.Minute).UTC().Format(time.RFC3339)
+	liveStreams := map[string]string{
+		"2000": `{"id":"1","user_id":"2000","user_login":"streamer","user_name":"Streamer","game_id":"100",` +
+			`"game_name":"Minecraft","type":"live","title":"Title","started_at":"` + startedAt + `"}`,
+		"3000": `{"id":"2","user_id":"3000","user_login":"other","user_name":"Other","type":"live",` +
+			`"title":"Other Title"}`,
+		"5000": `{"id":"5","user_id":"5000","user_login":"stranger","user_name":"Stranger","game_id":"200",` +
+			`"game_name":"Chess","type":"live","title":"Stranger Title","viewer_count":1234,` +
+			`"thumbnail_url":"https://localhost/{width}x{height}.jpg"}`,
+		"7000": `{"id":"7","user_id":"7000","user_login":"crafter","user_name":"Crafter","game_id":"100",` +
+			`"game_name":"Minecraft","type":"live","title":"Crafting","viewer_count":9000}`,
+	}
+	// The top streams for each game, the most watched first
+	gameStreams := map[string][]string{"100": {"7000", "2000"}, "200": {"5000"}}
+	topStreams := []string{"7000", "5000"}
+	categories := map[string]string{
+		"minecraft": `[{"id":"100","name":"Minecraft"},{"id":"101","name":"Minecraft Dungeons"}]`,
+		"chess":     `[{"id":"200","name":"Chess"}]`,
+		"tetris":    `[{"id":"300","name":"Tetris"}]`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == twitch.ValidateTokenPath:
+			w.Write([]byte(`{"client_id":"test","login":"viewer","user_id":"1000",` +
+				`"scopes":["user:read:follows"],"expires_in":3600}`))
+		case r.URL.Path == "/users":
+			w.Write([]byte(`{"data":[{"id":"2000","login":"streamer","display_name":"Streamer"}]}`))
+		case r.URL.Path == "/streams/followed" && env.followsOffline:
+			w.Write([]byte(`{"data":[]}`))
+		case r.URL.Path == "/streams/followed":
+			w.Write([]byte(`{"data":[` + liveStreams["2000"] + `,` + liveStreams["3000"] + `]}`))
+		case r.URL.Path == "/channels/followed":
+			w.Write([]byte(`{"total":3,"data":[{"broadcaster_id":"2000","broadcaster_login":"streamer",` +
+				`"broadcaster_name":"Streamer"},{"broadcaster_id":"3000","broadcaster_login":"other",` +
+				`"broadcaster_name":"Other"},{"broadcaster_id":"4000","broadcaster_login":"sleepy",` +
+				`"broadcaster_name":"Sleepy"}]}`))
+		case r.URL.Path == "/streams":
+			query := r.URL.Query()
+			userIDs := query["user_id"]
+			for _, gameID := range query["game_id"] {
+				userIDs = append(userIDs, gameStreams[gameID]...)
+			}
+			if len(query["user_id"]) == 0 && len(query["game_id"]) == 0 {
+				userIDs = topStreams
+			}
+			if query.Get("language") == "de" {
+				userIDs = nil
+			}
+
+			streams := make([]string, 0)
+			for _, userID := range userIDs {
+				if stream, ok := liveStreams[userID]; ok {
+					streams = append(streams, stream)
+				}
+			}
+			w.Write([]byte(`{"data":[` + strings.Join(streams, ",") + `]}`))
+		case r.URL.Path == "/search/categories":
+			if games, ok := categories[r.URL.Query().Get("query")]; ok {
+				w.Write([]byte(`{"data":` + games + `}`))
+				return
+			}
+			w.Write([]byte(`{"data":[]}`))
+		case r.URL.Path == "/games":
+			w.Write([]byte(`{"data":[]}`))
+		case r.URL.Path == "/search/channels":
+			if r.URL.Query().Get("query") == "nobody" {
+				w.Write([]byte(`{"data":[]}`))
+				return
+			}
+			w.Write([]byte(`{"data":[{"id":"5000","broadcaster_login":"stranger","display_name":"Stranger",` +
+				`"is_live":true},{"id":"6000","broadcaster_login":"strangerthings","display_name":"StrangerThings"}]}`))
+		case r.URL.Path == "/videos":
+			if r.URL.Query().Get("user_id") == "4000" || r.URL.Query().Get("id") == "100" {
+				w.Write([]byte(`{"data":[{"id":"100","user_id":"4000","user_name":"Sleepy","title":"Last Night",` +
+					`"thumbnail_url":"https://localhost/%{width}x%{height}.jpg"}]}`))
+				return
+			}
+			w.Write([]byte(`{"data":[]}`))
+		case strings.HasPrefix(r.URL.Path, "/api/channel/hls/"), strings.HasPrefix(r.URL.Path, "/vod/"):
+			w.Write([]byte(testMasterPlaylist))
+		default:
+			t.Errorf("Unexpected request to the stand-in server: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	client := twitch.NewClient("test")
+	client.APIBaseURL = server.URL
+	client.AuthBaseURL = server.URL
+	client.UsherBaseURL = server.URL
+	client.PlaybackTokens = testPlaybackTokens{}
+
+	env.server = server
+	InitEnv(Env{
+		Client:       client,
+		History:      env.history,
+		Queues:       env.queues,
+		Recorder:     env.recorder,
+		StreamTokens: NewStreamTokenSigner([]byte("test")),
+	})
+
+	return env
+}
+
+func (env *testEnv) Close() {
+	env.server.Close()
+}
+
+// play will make the channel the user's current stream.
+func (env *testEnv) play(userID, channelID string) {
+	env.queues.UpdateQueue(userID, func(queue *twitch.PlaybackQueue) error {
+		queue.Refresh([]string{channelID})
+		queue.JumpTo(channelID)
+		return nil
+	})
+}
+
+// testRequest will decode the raw request body, as skillserver would, into a Request.
+func testRequest(body []byte) *Request {
+	request, err := NewRequest(body)
+	if err != nil {
+		panic("invalid test request body: " + err.Error())
+	}
+
+	return request
+}
+
+// newTestIntentRequest will create an intent request from the linked test user.
+func newTestIntentRequest(intentName string) *Request {
+	echoRequest := &skillserver.EchoRequest{}
+	echoRequest.Session.User.AccessToken = "token"
+	echoRequest.Request.Type = IntentRequestType
+	echoRequest.Request.Intent.Name = intentName
+
+	return &Request{EchoRequest: echoRequest}
+}
+
+func TestResumeAudioStreamUsesAudioPlayerToken(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+	env.play("1000", "2000")
+
+	token := streamTokens.Sign(NewStreamToken("3000", "audio_only"))
+	body := []byte(`{"context": {"AudioPlayer": {"token": "` + token + `", "offsetInMilliseconds": 5000,` +
+		` "playerActivity": "STOPPED"}}}`)
+
+	request := newTestIntentRequest("AMAZON.ResumeIntent")
+	request.Body = body
+	response := ResumeAudioStream(request)
+	if response.Response.OutputSpeech == nil || response.Response.OutputSpeech.Text != "Starting stream for Other" {
+		t.Fatalf("Expected the stream from the AudioPlayer token to resume: %+v", response.Response.OutputSpeech)
+	}
+
+	queue, _ := env.queues.Queue("1000")
+	if queue.Current() != "3000" {
+		t.Fatalf("Resuming should make the channel current in the queue, got %s", queue.Current())
+	}
+}
+
+func TestResumeAudioStreamWithoutToken(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+	env.play("1000", "2000")
+
+	response := ResumeAudioStream(newTestIntentRequest("AMAZON.ResumeIntent"))
+	if response.Response.OutputSpeech == nil || response.Response.OutputSpeech.Text != "Starting stream for Streamer" {
+		t.Fatalf("Expected the queue's current stream to resume: %+v", response.Response.OutputSpeech)
+	}
+
+	directive := response.Response.Directives[0].(*skillserver.AudioDirective)
+	token, err := streamTokens.Parse(directive.AudioItem.Stream.Token)
+	if err != nil || token.ChannelID != "2000" || token.Variant != "audio_only" {
+		t.Fatalf("Expected a signed token for the stream: %+v (err=%v)", token, err)
+	}
+}
@@ -0,0 +1,248 @@
+package alexa
+
+import (
+	"time"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// The AudioPlayer request types sent by Alexa as playback progresses.
+const (
+	PlaybackStarted        = "AudioPlayer.PlaybackStarted"
+	PlaybackStopped        = "AudioPlayer.PlaybackStopped"
+	PlaybackFinished       = "AudioPlayer.PlaybackFinished"
+	PlaybackNearlyFinished = "AudioPlayer.PlaybackNearlyFinished"
+	PlaybackFailed         = "AudioPlayer.PlaybackFailed"
+)
+
+// MediaErrorInvalidRequest is the PlaybackFailed error type sent when the stream URL was
+// rejected, usually because the playback token in the URL expired.
+const MediaErrorInvalidRequest = "MEDIA_ERROR_INVALID_REQUEST"
+
+// FailedStartWindow is how soon after a stream starts a failure means the variant itself
+// can't be played. Reloading the same variant is only tried after this window so a broken
+// variant can't cause an endless loop of failures.
+const FailedStartWindow = 30 * time.Second
+
+// ListeningRecorder keeps a record of the streams each user actually listened to.
+type ListeningRecorder interface {
+	RecordListening(userID, streamUserID string, startedAt, endedAt time.Time) error
+}
+
+// audioPlayerEventContext is the user and stream an AudioPlayer event was sent for.
+type audioPlayerEventContext struct {
+	accessToken string
+	user        *twitch.User
+	channelID   string
+	// token is the decoded stream token, nil if the event's token was not valid.
+	token *StreamToken
+}
+
+// AudioPlayerHandler will respond to the AudioPlayer requests sent as the stream plays on the
+// user's device. The AudioPlayer fields are decoded from the raw request body since
+// skillserver doesn't decode any of them. Responses to these requests can only contain
+// AudioPlayer directives.
+func AudioPlayerHandler(request *Request) (response *skillserver.EchoResponse) {
+
+	response = skillserver.NewEchoResponse()
+	audioPlayerRequest, err := ParseAudioPlayerRequest(request.Body)
+	if err != nil {
+		glg.Errorf("Failed to decode AudioPlayer request: %s", err.Error())
+		return
+	}
+
+	event := &audioPlayerRequest.Request
+	user := audioPlayerRequest.Context.System.User
+	eventContext := loadAudioPlayerEventContext(user.AccessToken, event.Token)
+	userID := user.UserID
+	if eventContext.user != nil {
+		userID = eventContext.user.ID
+	}
+	glg.Infof("%s: user=%s channel=%s variant=%s offset=%v", event.Type, userID,
+		eventContext.channelID, eventContext.variant(), event.Offset())
+
+	switch event.Type {
+	case PlaybackStarted:
+		// Nothing is recorded until playback stops, the offset then says how long it played
+	case PlaybackStopped, PlaybackFinished:
+		recordListening(eventContext, event)
+	case PlaybackNearlyFinished:
+		if eventContext.vodID() != "" {
+			// A past broadcast really is finished, there is nothing to continue with
+			return
+		}
+		variant, err := resolveAudioStream(eventContext, false)
+		if err != nil {
+			glg.Errorf("Failed to load the stream to enqueue for channel %s: %s", eventContext.channelID, err.Error())
+			return
+		}
+		token := streamTokens.Sign(NewStreamToken(eventContext.channelID, variant.Name))
+		appendDirective(response, NewEnqueueAudioDirective(variant.URI, token, event.Token))
+	case PlaybackFailed:
+		errorType := ""
+		if event.Error != nil {
+			errorType = event.Error.Type
+			glg.Errorf("Playback failed for user=%s channel=%s: %s %s", userID, eventContext.channelID,
+				event.Error.Type, event.Error.Message)
+		}
+		recordListening(eventContext, event)
+
+		// An invalid request is usually an expired playback token so the same variant is
+		// loaded again with a new token, otherwise try a lower quality variant.
+		lowerQuality := errorType != MediaErrorInvalidRequest || eventContext.startedRecently(event)
+		variant, err := resolveAudioStream(eventContext, lowerQuality)
+		if err != nil {
+			glg.Errorf("Failed to recover playback for channel %s: %s", eventContext.channelID, err.Error())
+			return
+		}
+		glg.Infof("Recovering playback for user=%s channel=%s with variant %s", userID,
+			eventContext.channelID, variant.Name)
+		token := NewStreamToken(eventContext.channelID, variant.Name)
+		token.VODID = eventContext.vodID()
+		response.AppendAudioDirective(NewAudioDirectiveWithStreamURL(variant.URI, streamTokens.Sign(token)))
+	default:
+		glg.Warnf("Unhandled AudioPlayer request type: %s", event.Type)
+	}
+
+	return
+}
+
+// loadAudioPlayerEventContext will find the Twitch user for the access token and decode the
+// stream token. If the stream token isn't valid, the current stream in the user's queue is
+// used as the channel. Any missing details are left empty.
+func loadAudioPlayerEventContext(accessToken, tokenValue string) *audioPlayerEventContext {
+	eventContext := &audioPlayerEventContext{accessToken: accessToken}
+
+	token, err := streamTokens.Parse(tokenValue)
+	if err != nil {
+		glg.Warnf("Ignoring AudioPlayer token(%s): %s", tokenValue, err.Error())
+	} else {
+		eventContext.token = token
+		eventContext.channelID = token.ChannelID
+	}
+
+	if accessToken == "" {
+		return eventContext
+	}
+
+	tokenInfo, err := twitchClient.RequireToken(accessToken)
+	if err != nil {
+		glg.Warnf("Failed to validate the access token for an AudioPlayer request: %s", err.Error())
+		return eventContext
+	}
+	eventContext.user = tokenInfo.User()
+
+	if eventContext.channelID == "" {
+		queue, err := queues.Queue(eventContext.user.ID)
+		if err != nil {
+			glg.Warnf("Failed to load the playback queue for an AudioPlayer request: %s", err.Error())
+			return eventContext
+		}
+		eventContext.channelID = queue.Current()
+	}
+
+	return eventContext
+}
+
+// variant will return the name of the variant that was playing, or the empty string if it
+// is not known.
+func (eventContext *audioPlayerEventContext) variant() string {
+	if eventContext.token == nil {
+		return ""
+	}
+
+	return eventContext.token.Variant
+}
+
+// vodID will return the ID of the past broadcast that was playing, or the empty string if
+// a live stream was playing.
+func (eventContext *audioPlayerEventContext) vodID() string {
+	if eventContext.token == nil {
+		return ""
+	}
+
+	return eventContext.token.VODID
+}
+
+// startedRecently will return true if the event happened within the FailedStartWindow of the
+// stream starting.
+func (eventContext *audioPlayerEventContext) startedRecently(event *AudioPlayerEvent) bool {
+	if eventContext.token == nil {
+		return false
+	}
+
+	return event.Time().Sub(eventContext.token.Started()) < FailedStartWindow
+}
+
+// recordListening will save how long the user listened to the channel.
+func recordListening(eventContext *audioPlayerEventContext, event *AudioPlayerEvent) {
+	if recorder == nil || eventContext.user == nil || eventContext.channelID == "" {
+		return
+	}
+
+	endedAt := event.Time()
+	err := recorder.RecordListening(eventContext.user.ID, eventContext.channelID,
+		endedAt.Add(-event.Offset()), endedAt)
+	if err != nil {
+		glg.Errorf("Failed to record listening history: %s", err.Error())
+	}
+}
+
+// resolveAudioStream will load a new audio stream variant for the channel being played. The
+// same variant that was playing is used if it is known. If lowerQuality is true, the variant
+// with the next lower bandwidth than the one that was playing is used instead. An error is
+// returned if the variant that was playing already had the lowest bandwidth.
+func resolveAudioStream(eventContext *audioPlayerEventContext, lowerQuality bool) (*twitch.StreamVariant, error) {
+	if eventContext.channelID == "" {
+		return nil, &twitch.Error{Kind: twitch.ErrNotFound, Op: "resolve audio stream",
+			Message: "no channel is playing"}
+	}
+
+	selection, err := loadAudioStream(eventContext)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := append([]*twitch.StreamVariant{selection.Selected}, selection.Fallbacks...)
+	current := candidates[0]
+	for _, variant := range candidates {
+		if variant.Name == eventContext.variant() {
+			current = variant
+		}
+	}
+
+	if !lowerQuality {
+		return current, nil
+	}
+
+	var lower *twitch.StreamVariant
+	for _, variant := range candidates {
+		if variant.Bandwidth < current.Bandwidth && (lower == nil || variant.Bandwidth > lower.Bandwidth) {
+			lower = variant
+		}
+	}
+	if lower == nil {
+		return nil, &twitch.Error{Kind: twitch.ErrNotFound, Op: "resolve audio stream",
+			Message: "no lower quality variants left to try"}
+	}
+
+	return lower, nil
+}
+
+// loadAudioStream will load the audio variants for the past broadcast or live stream that
+// was playing.
+func loadAudioStream(eventContext *audioPlayerEventContext) (*twitch.VariantSelection, error) {
+	constraints := twitch.VariantConstraints{AudioOnly: true}
+	if vodID := eventContext.vodID(); vodID != "" {
+		return twitchClient.GetVODStream(vodID, constraints)
+	}
+
+	channel, err := twitchClient.GetUserByID(eventContext.accessToken, eventContext.channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return twitchClient.GetStream(channel.Login, constraints)
+}
@@ -0,0 +1,214 @@
+package alexa
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+func audioPlayerRequestBody(requestType, accessToken, token string, offset int64, extra string) []byte {
+	return []byte(fmt.Sprintf(`{
+	"context": {"System": {"user": {"userId": "amzn1.ask.account.test", "accessToken": %q}}},
+	"request": {"type": %q, "requestId": "id", "timestamp": %q,
+		"token": %q, "offsetInMilliseconds": %d %s}
+}`, accessToken, requestType, time.Now().UTC().Format(time.RFC3339), token, offset, extra))
+}
+
+// signedTestToken will create a stream token for the channel and variant that was started
+// the provided time ago.
+func signedTestToken(channelID, variant string, age time.Duration) string {
+	token := NewStreamToken(channelID, variant)
+	token.StartedAt = time.Now().Add(-age).Unix()
+	return streamTokens.Sign(token)
+}
+
+func TestParseAudioPlayerRequest(t *testing.T) {
+	body := []byte(`{
+	"context": {"System": {"user": {"userId": "amzn1.ask.account.test", "accessToken": "token"}}},
+	"request": {"type": "AudioPlayer.PlaybackFailed", "requestId": "id", "timestamp": "2017-12-01T12:00:00Z",
+		"token": "12345", "offsetInMilliseconds": 1500,
+		"error": {"type": "MEDIA_ERROR_SERVICE_UNAVAILABLE", "message": "unavailable"}}
+}`)
+
+	request, err := ParseAudioPlayerRequest(body)
+	if err != nil {
+		t.Fatalf("Failed to parse AudioPlayer request: %s", err.Error())
+	}
+
+	event := request.Request
+	if request.Context.System.User.AccessToken != "token" || event.Type != PlaybackFailed ||
+		event.Offset() != 1500*time.Millisecond || event.Error == nil ||
+		event.Error.Type != "MEDIA_ERROR_SERVICE_UNAVAILABLE" {
+		t.Fatalf("Incorrect AudioPlayer request: %+v", request)
+	}
+
+	if !event.Time().Equal(time.Date(2017, 12, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Incorrect event time: %v", event.Time())
+	}
+}
+
+func TestCaptureRequestBody(t *testing.T) {
+	var captured []byte
+	var remaining []byte
+	next := func(w http.ResponseWriter, r *http.Request) {
+		captured = RequestBody(r)
+		remaining, _ = ioutil.ReadAll(r.Body)
+	}
+
+	r := httptest.NewRequest("POST", "/echo/twitch-box", strings.NewReader("body"))
+	CaptureRequestBody(httptest.NewRecorder(), r, next)
+
+	if string(captured) != "body" || string(remaining) != "body" {
+		t.Fatalf("Body was not captured and restored: captured=%q remaining=%q", captured, remaining)
+	}
+}
+
+func TestAudioPlayerHandlerRecordsListening(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+	token := signedTestToken("2000", "audio_only", time.Minute)
+
+	for _, requestType := range []string{PlaybackStarted, PlaybackStopped, PlaybackFinished} {
+		response := AudioPlayerHandler(testRequest(audioPlayerRequestBody(requestType, "token", token, 60000, "")))
+		if response.Response.OutputSpeech != nil || len(response.Response.Directives) != 0 {
+			t.Fatalf("%s should have an empty response: %+v", requestType, response.Response)
+		}
+	}
+
+	if len(env.recorder.records) != 2 {
+		t.Fatalf("Expected stopped and finished to be recorded, got %d records", len(env.recorder.records))
+	}
+	record := env.recorder.records[0]
+	if record.userID != "1000" || record.streamUserID != "2000" ||
+		record.endedAt.Sub(record.startedAt) != time.Minute {
+		t.Fatalf("Incorrect listening record: %+v", record)
+	}
+}
+
+func TestAudioPlayerHandlerWithoutUser(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	response := AudioPlayerHandler(testRequest(audioPlayerRequestBody(PlaybackStopped, "",
+		signedTestToken("2000", "audio_only", time.Minute), 1000, "")))
+	if len(response.Response.Directives) != 0 || len(env.recorder.records) != 0 {
+		t.Fatalf("Nothing should be recorded without a linked account")
+	}
+}
+
+func TestAudioPlayerHandlerUsesQueueForUnknownToken(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+	env.play("1000", "2000")
+
+	AudioPlayerHandler(testRequest(audioPlayerRequestBody(PlaybackStopped, "token", "12345", 1000, "")))
+	if len(env.recorder.records) != 1 || env.recorder.records[0].streamUserID != "2000" {
+		t.Fatalf("Expected the queue's current stream to be recorded: %+v", env.recorder.records)
+	}
+}
+
+func TestAudioPlayerHandlerRecoversFailedPlayback(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	cases := []struct {
+		name        string
+		errorType   string
+		token       string
+		expectedURL string
+	}{
+		{"ExpiredURL", MediaErrorInvalidRequest, signedTestToken("2000", "audio_only", time.Hour),
+			"http://localhost/audio_only.m3u8"},
+		{"ExpiredURLRightAfterStart", MediaErrorInvalidRequest, signedTestToken("2000", "160p30", time.Second),
+			"http://localhost/audio_only.m3u8"},
+		{"ServiceUnavailable", "MEDIA_ERROR_SERVICE_UNAVAILABLE", signedTestToken("2000", "160p30", time.Hour),
+			"http://localhost/audio_only.m3u8"},
+		{"LowestVariantFailed", "MEDIA_ERROR_SERVICE_UNAVAILABLE", signedTestToken("2000", "audio_only", time.Hour), ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			body := audioPlayerRequestBody(PlaybackFailed, "token", c.token, 0,
+				fmt.Sprintf(`, "error": {"type": %q, "message": "failed"}`, c.errorType))
+			response := AudioPlayerHandler(testRequest(body))
+
+			if response.Response.OutputSpeech != nil {
+				t.Fatalf("AudioPlayer responses can't include speech: %+v", response.Response)
+			}
+			if c.expectedURL == "" {
+				if len(response.Response.Directives) != 0 {
+					t.Fatalf("Expected recovery to give up: %+v", response.Response.Directives)
+				}
+				return
+			}
+
+			json, _ := response.String()
+			if len(response.Response.Directives) != 1 ||
+				!strings.Contains(string(json), `"url":"`+c.expectedURL+`"`) ||
+				!strings.Contains(string(json), "REPLACE_ALL") {
+				t.Fatalf("Incorrect recovery directive: %s", json)
+			}
+
+			directive := response.Response.Directives[0].(*skillserver.AudioDirective)
+			token, err := streamTokens.Parse(directive.AudioItem.Stream.Token)
+			if err != nil || token.ChannelID != "2000" {
+				t.Fatalf("Recovery directive should have a signed token for the channel: %v", err)
+			}
+		})
+	}
+}
+
+func TestAudioPlayerHandlerEnqueuesWhenNearlyFinished(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	previousToken := signedTestToken("2000", "audio_only", time.Hour)
+	response := AudioPlayerHandler(testRequest(audioPlayerRequestBody(PlaybackNearlyFinished, "token", previousToken, 0, "")))
+	if len(response.Response.Directives) != 1 {
+		t.Fatalf("Expected an enqueue directive: %+v", response.Response)
+	}
+
+	directive := response.Response.Directives[0].(*EnqueueAudioDirective)
+	if directive.PlayBehavior != "ENQUEUE" ||
+		directive.AudioItem.Stream.ExpectedPreviousToken != previousToken ||
+		directive.AudioItem.Stream.Token == previousToken {
+		t.Fatalf("Incorrect enqueue directive: %+v", directive)
+	}
+	if _, err := streamTokens.Parse(directive.AudioItem.Stream.Token); err != nil {
+		t.Fatalf("Enqueued stream should have a signed token: %s", err.Error())
+	}
+}
+
+func TestAudioPlayerHandlerPastBroadcast(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	token := NewStreamToken("4000", "audio_only")
+	token.VODID = "100"
+	token.StartedAt = time.Now().Add(-time.Hour).Unix()
+	vodToken := streamTokens.Sign(token)
+
+	response := AudioPlayerHandler(testRequest(audioPlayerRequestBody(PlaybackNearlyFinished, "token", vodToken, 0, "")))
+	if len(response.Response.Directives) != 0 {
+		t.Fatalf("Nothing should be enqueued after a past broadcast: %+v", response.Response)
+	}
+
+	body := audioPlayerRequestBody(PlaybackFailed, "token", vodToken, 0,
+		`, "error": {"type": "MEDIA_ERROR_INVALID_REQUEST", "message": "expired"}`)
+	response = AudioPlayerHandler(testRequest(body))
+	if len(response.Response.Directives) != 1 {
+		t.Fatalf("Expected the past broadcast to be reloaded: %+v", response.Response)
+	}
+
+	directive := response.Response.Directives[0].(*skillserver.AudioDirective)
+	recovered, err := streamTokens.Parse(directive.AudioItem.Stream.Token)
+	if err != nil || recovered.VODID != "100" || !strings.HasPrefix(directive.AudioItem.Stream.URL, "http://localhost/") {
+		t.Fatalf("Expected the past broadcast to be reloaded(%v): %+v", err, directive.AudioItem.Stream)
+	}
+}
@@ -0,0 +1,96 @@
+package alexa
+
+import (
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// The limits for the games the user plays often that are used to filter the browsed streams.
+const (
+	FavoriteGamesLimit   = 3
+	MinFavoriteGamePlays = 3
+)
+
+// browseOfferAttribute is the session attribute that keeps the offer to browse the most
+// watched live streams until the user says yes or no.
+const browseOfferAttribute = "browseOffer"
+
+// browseOffer is the offer to play the most watched live streams, it is kept in the session
+// attributes until the user replies.
+type browseOffer struct {
+	Language string   `json:"language,omitempty"`
+	GameIDs  []string `json:"gameIds,omitempty"`
+}
+
+// newBrowseOffer will create the offer for the user's language and the games they play
+// often. If the user's queue can't be loaded the streams aren't filtered by game.
+func newBrowseOffer(user *twitch.User, language string) *browseOffer {
+	offer := &browseOffer{Language: language}
+
+	queue, err := queues.Queue(user.ID)
+	if err != nil {
+		glg.Warnf("Failed to load the favorite games for browsing: %s", err.Error())
+		return offer
+	}
+	offer.GameIDs = queue.FavoriteGames(FavoriteGamesLimit, MinFavoriteGamePlays)
+
+	return offer
+}
+
+// offerBrowseResponse will tell the user none of their followed channels are live and ask if
+// they would like to play one of the most watched live streams instead.
+func offerBrowseResponse(offer *browseOffer) (response *skillserver.EchoResponse) {
+
+	response = skillserver.NewEchoResponse()
+	flag := false
+	response.OutputSpeech("None of your followed channels are live right now. Would you like to " +
+		"listen to one of the top live streams instead?").
+		Reprompt("Would you like to listen to one of the top live streams?").
+		EndSession(&flag)
+	SetSessionAttribute(response, browseOfferAttribute, offer)
+	askQuestion(response, browseQuestion)
+
+	return
+}
+
+// AcceptBrowse will play the most watched live stream after the user accepted the offer to
+// browse. Next and previous then move through the most watched live streams until the user
+// asks to play something else.
+func AcceptBrowse(request *Request) (response *skillserver.EchoResponse) {
+
+	offer := &browseOffer{}
+	if !request.SessionAttribute(browseOfferAttribute, offer) {
+		return UnknownRequest(request)
+	}
+
+	streamRequest := newStreamRequest(request, twitch.PLAY)
+	streamRequest.source = &twitch.QueueSource{Browse: true, Language: offer.Language, GameIDs: offer.GameIDs}
+
+	return startStream(streamRequest)
+}
+
+// DeclineBrowse will end the session after the user declined the offer to browse.
+func DeclineBrowse(request *Request) (response *skillserver.EchoResponse) {
+
+	if !request.SessionAttribute(browseOfferAttribute, &browseOffer{}) {
+		return UnknownRequest(request)
+	}
+
+	return skillserver.NewEchoResponse().OutputSpeech("Okay, maybe next time.")
+}
+
+// loadBrowseStreams will load the most watched live streams in the source's language. If
+// nobody is streaming the source's games, the streams from every game are used instead.
+func loadBrowseStreams(source *twitch.QueueSource) (*twitch.StreamsResponse, error) {
+
+	filter := &twitch.StreamsFilter{GameIDs: source.GameIDs, Language: source.Language}
+	streams, err := twitchClient.GetTopStreams(filter)
+	if err == nil && len(streams.Data) == 0 && len(filter.GameIDs) > 0 {
+		glg.Infof("Nobody is streaming games %v, browsing every game instead", filter.GameIDs)
+		filter.GameIDs = nil
+		streams, err = twitchClient.GetTopStreams(filter)
+	}
+
+	return streams, err
+}
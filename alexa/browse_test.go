@@ -0,0 +1,142 @@
+package alexa
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// newBrowseTestEnv will create the test environment with none of the followed channels live
+// and browsing enabled.
+func newBrowseTestEnv(t *testing.T) *testEnv {
+	env := newTestEnv(t)
+	env.followsOffline = true
+	browseTopStreams = true
+
+	return env
+}
+
+func closeBrowseTestEnv(env *testEnv) {
+	browseTopStreams = false
+	env.Close()
+}
+
+func TestBrowseTopStreams(t *testing.T) {
+	env := newBrowseTestEnv(t)
+	defer closeBrowseTestEnv(env)
+
+	response := StartAudioStream(newTestIntentRequest("StartAudioStream"))
+	if speech := responseSpeech(response); !strings.Contains(speech, "listen to one of the top live streams") {
+		t.Fatalf("Expected the top streams to be offered, got %q", speech)
+	}
+	if len(response.Response.Directives) != 0 || response.Response.ShouldEndSession == nil ||
+		*response.Response.ShouldEndSession {
+		t.Fatalf("Expected the session to stay open for the user's reply: %+v", response.Response)
+	}
+
+	router := NewSkillRouter()
+	steps := []struct {
+		intentName string
+		speech     string
+	}{
+		{"AMAZON.NextIntent", "Starting stream for Stranger"},
+		{"AMAZON.PreviousIntent", "Starting stream for Crafter"},
+		{"AMAZON.NextIntent", "Starting stream for Stranger"},
+	}
+
+	response = router.Route(nextTurn(t, response, "AMAZON.YesIntent", nil))
+	if speech := responseSpeech(response); speech != "Starting stream for Crafter" {
+		t.Fatalf("Expected the most watched stream to be played, got %q", speech)
+	}
+	for _, step := range steps {
+		response = router.Route(newTestIntentRequest(step.intentName))
+		if speech := responseSpeech(response); speech != step.speech {
+			t.Fatalf("%s: expected %q, got %q", step.intentName, step.speech, speech)
+		}
+	}
+
+	queue, _ := env.queues.Queue("1000")
+	if queue.Source == nil || !queue.Source.Browse {
+		t.Fatalf("Expected the queue to be browsing the top streams: %+v", queue.Source)
+	}
+}
+
+func TestDeclineBrowse(t *testing.T) {
+	env := newBrowseTestEnv(t)
+	defer closeBrowseTestEnv(env)
+
+	response := StartAudioStream(newTestIntentRequest("StartAudioStream"))
+	response = DeclineBrowse(nextTurn(t, response, "AMAZON.NoIntent", nil))
+	if speech := responseSpeech(response); speech != "Okay, maybe next time." || len(response.Response.Directives) != 0 {
+		t.Fatalf("Expected nothing to be played, got %q", speech)
+	}
+}
+
+func TestBrowseDisabled(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+	env.followsOffline = true
+
+	response := StartAudioStream(newTestIntentRequest("StartAudioStream"))
+	if speech := responseSpeech(response); !strings.Contains(speech, "none of your followed channels are live") {
+		t.Fatalf("Expected the top streams not to be offered, got %q", speech)
+	}
+}
+
+func TestBrowseFilters(t *testing.T) {
+	cases := []struct {
+		name      string
+		locale    string
+		gamePlays []string
+		gameIDs   []string
+		speech    string
+	}{
+		{name: "Language", locale: "de-DE", speech: "couldn't find any live streams"},
+		{name: "FavoriteGame", locale: "en-US", gamePlays: []string{"200", "200", "200", "100"},
+			gameIDs: []string{"200"}, speech: "Starting stream for Stranger"},
+		{name: "NobodyStreamingFavoriteGame", gamePlays: []string{"300", "300", "300"},
+			gameIDs: []string{"300"}, speech: "Starting stream for Crafter"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			env := newBrowseTestEnv(t)
+			defer closeBrowseTestEnv(env)
+
+			env.queues.UpdateQueue("1000", func(queue *twitch.PlaybackQueue) error {
+				for _, gameID := range c.gamePlays {
+					queue.CountGamePlay(gameID)
+				}
+				return nil
+			})
+
+			request := newTestIntentRequest("StartAudioStream")
+			request.Body = []byte(`{"request": {"locale": "` + c.locale + `"}}`)
+			response := StartAudioStream(request)
+
+			offer := &browseOffer{}
+			reply := nextTurn(t, response, "AMAZON.YesIntent", nil)
+			if !reply.SessionAttribute(browseOfferAttribute, offer) || offer.Language != localeLanguage(c.locale) ||
+				!reflect.DeepEqual(offer.GameIDs, c.gameIDs) {
+				t.Fatalf("Incorrect browse offer: %+v", offer)
+			}
+
+			if speech := responseSpeech(AcceptBrowse(reply)); !strings.Contains(speech, c.speech) {
+				t.Fatalf("Expected speech containing %q, got %q", c.speech, speech)
+			}
+		})
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	locale := ParseLocale([]byte(`{"request": {"type": "IntentRequest", "locale": "en-GB"}}`))
+	if locale != "en-GB" || localeLanguage(locale) != "en" {
+		t.Fatalf("Incorrect locale %q", locale)
+	}
+
+	if locale := ParseLocale(nil); locale != "" || localeLanguage(locale) != "" {
+		t.Fatalf("Expected no locale without a request body, got %q", locale)
+	}
+}
@@ -0,0 +1,177 @@
+package alexa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/matcher"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// StreamChoicesPageSize is the number of live channels read to the user at a time while they
+// are choosing a stream.
+const StreamChoicesPageSize = 3
+
+// NumberSlot is the slot in the SelectStream intent with the number of the chosen stream.
+const NumberSlot = "Number"
+
+// streamChoicesAttribute is the session attribute that keeps the streams the user is
+// choosing between.
+const streamChoicesAttribute = "streamChoices"
+
+// streamChoice is a live channel the user can choose to play.
+type streamChoice struct {
+	ID    string `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+	Game  string `json:"game,omitempty"`
+}
+
+// streamChoices are the live channels the user is choosing between. They are kept in the
+// session attributes between each turn of the dialog.
+type streamChoices struct {
+	Choices []streamChoice `json:"choices"`
+	// Offset is the index of the first choice on the page that was last read to the user.
+	Offset int `json:"offset"`
+}
+
+// newStreamChoices will create the choices for the live streams, in the same order.
+func newStreamChoices(liveStreams []*twitch.Stream) *streamChoices {
+	choices := &streamChoices{Choices: make([]streamChoice, 0, len(liveStreams))}
+	for _, stream := range liveStreams {
+		choices.Choices = append(choices.Choices, streamChoice{ID: stream.UserID, Login: stream.UserLogin,
+			Name: stream.UserName, Game: stream.GameName})
+	}
+
+	return choices
+}
+
+// ChooseStream will ask the user which of their live followed channels they would like to
+// play. If only one followed channel is live, it is played without asking.
+func ChooseStream(request *Request) (response *skillserver.EchoResponse) {
+
+	streamRequest := newStreamRequest(request, twitch.PLAY)
+	streamRequest.chooseStream = true
+
+	return startStream(streamRequest)
+}
+
+// MoreStreams will read the next page of live channels to a user who is choosing a stream.
+// After the last page, the list starts again from the top.
+func MoreStreams(request *Request) (response *skillserver.EchoResponse) {
+
+	choices := &streamChoices{}
+	if !request.SessionAttribute(streamChoicesAttribute, choices) || len(choices.Choices) == 0 {
+		return UnknownRequest(request)
+	}
+
+	choices.Offset += StreamChoicesPageSize
+	if choices.Offset >= len(choices.Choices) {
+		choices.Offset = 0
+		response = chooseStreamResponse(choices)
+		response.OutputSpeech("That's all of them, starting again from the top. " +
+			response.Response.OutputSpeech.Text)
+		return
+	}
+
+	return chooseStreamResponse(choices)
+}
+
+// SelectStream will play the stream the user chose by number or by name. If the reply can't
+// be matched to one of the choices the user is asked again.
+func SelectStream(request *Request) (response *skillserver.EchoResponse) {
+
+	choices := &streamChoices{}
+	if !request.SessionAttribute(streamChoicesAttribute, choices) || len(choices.Choices) == 0 {
+		return UnknownRequest(request)
+	}
+
+	choice := choices.selected(request.Request.Intent.Slots)
+	if choice == nil {
+		response = chooseStreamResponse(choices)
+		response.OutputSpeech("Sorry, I didn't catch which stream you wanted. Say a number or a name, " +
+			"or say more to hear other channels.")
+		return
+	}
+	glg.Infof("User chose stream for channel %s", choice.Login)
+
+	// Resuming the chosen channel makes it the current stream in the user's queue, so next
+	// and previous continue from it.
+	streamRequest := newStreamRequest(request, twitch.RESUME)
+	streamRequest.resumeChannelID = choice.ID
+	streamRequest.keepSource = false
+
+	return startStream(streamRequest)
+}
+
+// selected will find the choice for the number or name the user replied with, nil is
+// returned if the reply doesn't match any of the choices.
+func (choices *streamChoices) selected(slots map[string]skillserver.EchoSlot) *streamChoice {
+
+	if number, err := strconv.Atoi(slots[NumberSlot].Value); err == nil {
+		if number < 1 || number > len(choices.Choices) {
+			return nil
+		}
+		return &choices.Choices[number-1]
+	}
+
+	spoken := slots[ChannelSlot].Value
+	if strings.TrimSpace(spoken) == "" {
+		return nil
+	}
+
+	candidates := make([]matcher.Candidate, 0, len(choices.Choices))
+	for index, choice := range choices.Choices {
+		candidates = append(candidates, matcher.Candidate{ID: strconv.Itoa(index),
+			Names: []string{choice.Login, choice.Name}})
+	}
+
+	matches := channelMatcher.Rank(spoken, candidates)
+	if len(matches) == 0 {
+		return nil
+	}
+	index, _ := strconv.Atoi(matches[0].Candidate.ID)
+
+	return &choices.Choices[index]
+}
+
+// chooseStreamResponse will read the current page of choices to the user and keep the
+// choices in the session for their reply. The choices are numbered from the first choice so
+// each number always means the same channel.
+func chooseStreamResponse(choices *streamChoices) (response *skillserver.EchoResponse) {
+
+	end := choices.Offset + StreamChoicesPageSize
+	if end > len(choices.Choices) {
+		end = len(choices.Choices)
+	}
+
+	speech := make([]string, 0, end-choices.Offset+2)
+	if choices.Offset == 0 {
+		speech = append(speech, fmt.Sprintf("%d of your followed channels are live.", len(choices.Choices)))
+	}
+	for index := choices.Offset; index < end; index++ {
+		choice := choices.Choices[index]
+		if choice.Game != "" {
+			speech = append(speech, fmt.Sprintf("%d, %s, playing %s.", index+1, choice.Name, choice.Game))
+		} else {
+			speech = append(speech, fmt.Sprintf("%d, %s.", index+1, choice.Name))
+		}
+	}
+	if end < len(choices.Choices) {
+		speech = append(speech, "Say a number or a name, or say more to hear the rest.")
+	} else {
+		speech = append(speech, "Say a number or a name.")
+	}
+
+	response = skillserver.NewEchoResponse()
+	flag := false
+	response.OutputSpeech(strings.Join(speech, " ")).
+		Reprompt("Which stream would you like to play? Say a number or a name.").
+		EndSession(&flag)
+	SetSessionAttribute(response, streamChoicesAttribute, choices)
+
+	return
+}
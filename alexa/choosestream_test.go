@@ -0,0 +1,136 @@
+package alexa
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+// nextTurn will create the intent request for the user's reply to the response. The
+// response's session attributes are sent back as JSON, the same way Alexa sends them.
+func nextTurn(t *testing.T, response *skillserver.EchoResponse, intentName string, slots map[string]string) *Request {
+	data, err := json.Marshal(response.SessionAttributes)
+	if err != nil {
+		t.Fatalf("Failed to encode the session attributes: %s", err.Error())
+	}
+
+	request := newTestIntentRequest(intentName)
+	json.Unmarshal(data, &request.Session.Attributes)
+	request.Request.Intent.Slots = make(map[string]skillserver.EchoSlot)
+	for name, value := range slots {
+		request.Request.Intent.Slots[name] = skillserver.EchoSlot{Name: name, Value: value}
+	}
+
+	return request
+}
+
+// testStreamChoices is a session with more live channels than fit on one page.
+func testStreamChoices() *skillserver.EchoResponse {
+	choices := &streamChoices{}
+	for _, name := range []string{"One", "Two", "Three", "Four", "Five"} {
+		choices.Choices = append(choices.Choices, streamChoice{ID: name, Login: strings.ToLower(name), Name: name})
+	}
+
+	return chooseStreamResponse(choices)
+}
+
+func TestChooseStream(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	response := ChooseStream(newTestIntentRequest("ChooseStream"))
+	speech := responseSpeech(response)
+	if !strings.Contains(speech, "2 of your followed channels are live. 1, Streamer, playing Minecraft. 2, Other.") {
+		t.Fatalf("Expected the live channels to be listed, got %q", speech)
+	}
+	if len(response.Response.Directives) != 0 || response.Response.ShouldEndSession == nil ||
+		*response.Response.ShouldEndSession {
+		t.Fatalf("Expected the session to stay open for the user's choice: %+v", response.Response)
+	}
+
+	cases := []struct {
+		name   string
+		slots  map[string]string
+		speech string
+	}{
+		{"Number", map[string]string{NumberSlot: "2"}, "Starting stream for Other"},
+		{"Name", map[string]string{ChannelSlot: "the other"}, "Starting stream for Other"},
+		{"InvalidNumber", map[string]string{NumberSlot: "9"}, "didn't catch which stream"},
+		{"UnknownName", map[string]string{ChannelSlot: "nobody"}, "didn't catch which stream"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			reply := SelectStream(nextTurn(t, response, "SelectStream", c.slots))
+			if speech := responseSpeech(reply); !strings.Contains(speech, c.speech) {
+				t.Fatalf("Expected speech containing %q, got %q", c.speech, speech)
+			}
+		})
+	}
+
+	SelectStream(nextTurn(t, response, "SelectStream", map[string]string{NumberSlot: "2"}))
+	queue, _ := env.queues.Queue("1000")
+	if queue.Current() != "3000" {
+		t.Fatalf("Expected the chosen channel to be the current stream, got %q", queue.Current())
+	}
+}
+
+func TestChooseStreamKeepsChoicesAfterInvalidReply(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	response := SelectStream(nextTurn(t, testStreamChoices(), "SelectStream", map[string]string{NumberSlot: "0"}))
+	choices := &streamChoices{}
+	if !nextTurn(t, response, "SelectStream", nil).SessionAttribute(streamChoicesAttribute, choices) ||
+		len(choices.Choices) != 5 {
+		t.Fatalf("Expected the choices to be kept in the session: %+v", response.SessionAttributes)
+	}
+}
+
+func TestMoreStreams(t *testing.T) {
+	response := testStreamChoices()
+	if speech := responseSpeech(response); !strings.Contains(speech, "3, Three. Say a number or a name, or say more") {
+		t.Fatalf("Expected the first page of choices, got %q", speech)
+	}
+
+	response = MoreStreams(nextTurn(t, response, "AMAZON.MoreIntent", nil))
+	if speech := responseSpeech(response); speech != "4, Four. 5, Five. Say a number or a name." {
+		t.Fatalf("Expected the second page of choices, got %q", speech)
+	}
+
+	response = MoreStreams(nextTurn(t, response, "AMAZON.MoreIntent", nil))
+	if speech := responseSpeech(response); !strings.HasPrefix(speech, "That's all of them") ||
+		!strings.Contains(speech, "1, One.") {
+		t.Fatalf("Expected the choices to start again from the top, got %q", speech)
+	}
+}
+
+func TestChooseStreamWithoutSession(t *testing.T) {
+	for _, handler := range []Handler{SelectStream, MoreStreams} {
+		response := handler(nextTurn(t, skillserver.NewEchoResponse(), "SelectStream",
+			map[string]string{NumberSlot: "1"}))
+		if !strings.Contains(responseSpeech(response), "did not understand") {
+			t.Fatalf("Expected replies outside of the dialog to be unknown, got %q", responseSpeech(response))
+		}
+	}
+}
+
+func TestStartAudioStreamAsksWhichStream(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	askWhichStream = true
+	defer func() { askWhichStream = false }()
+
+	response := StartAudioStream(newTestIntentRequest("StartAudioStream"))
+	if !strings.Contains(responseSpeech(response), "Say a number or a name") {
+		t.Fatalf("Expected the user to be asked which stream to play, got %q", responseSpeech(response))
+	}
+
+	response = StartAudioStream(newTestIntentRequest("AMAZON.NextIntent"))
+	if responseSpeech(response) != "Starting stream for Streamer" {
+		t.Fatalf("Only play requests should ask which stream, got %q", responseSpeech(response))
+	}
+}
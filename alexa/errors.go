@@ -0,0 +1,86 @@
+package alexa
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// ErrorResponse will build the response for an error returned from the twitch package. This
+// is the single place that decides what the user hears for each kind of Twitch error.
+func ErrorResponse(err error) (response *skillserver.EchoResponse) {
+
+	response = skillserver.NewEchoResponse()
+	kind := twitch.KindOf(err)
+	glg.Errorf("Responding to Twitch error(%s): %v", kind, err)
+
+	switch kind {
+	case twitch.ErrUnauthorized:
+		response.OutputSpeech("Sorry, it looks like your Twitch account needs to be linked " +
+			"again in the Alexa app.").
+			LinkAccountCard()
+	case twitch.ErrForbidden:
+		if twitchErr, ok := err.(*twitch.Error); ok && len(twitchErr.MissingScopes) > 0 {
+			response.OutputSpeech("Sorry, Twitch Box needs more permissions for your Twitch " +
+				"account. Please link your Twitch account again in the Alexa app.").
+				LinkAccountCard()
+			// The scope names aren't useful in speech but the card lets the user check
+			// which permissions they need to allow when linking again.
+			response.Response.Card.Content = "Twitch Box needs these permissions for your Twitch " +
+				"account: " + strings.Join(twitchErr.MissingScopes, ", ")
+			break
+		}
+		response.OutputSpeech("Sorry, Twitch did not allow access to your account. Please link " +
+			"your Twitch account again in the Alexa app.").
+			LinkAccountCard()
+	case twitch.ErrNotFound:
+		response.OutputSpeech("Sorry, I couldn't find that on Twitch.")
+	case twitch.ErrNotLive:
+		response.OutputSpeech("Sorry, it looks like that channel isn't live right now.")
+	case twitch.ErrGeoBlocked:
+		response.OutputSpeech("Sorry, that stream isn't available in your region.")
+	case twitch.ErrSubscriberOnly:
+		response.OutputSpeech("Sorry, that stream is only available to subscribers.")
+	case twitch.ErrRateLimited:
+		response.OutputSpeech(rateLimitedSpeech(err))
+	case twitch.ErrUpstream:
+		response.OutputSpeech("It looks like Twitch is having problems right now, please try again later.")
+	default:
+		response.OutputSpeech("There was a problem talking to Twitch, please try again later.")
+	}
+
+	return
+}
+
+// StorageErrorResponse will build the response for an error from one of the skill's own
+// stores, e.g. the listening history. These aren't problems with Twitch so the user only hears
+// that something went wrong.
+func StorageErrorResponse(err error) *skillserver.EchoResponse {
+	glg.Errorf("Responding to storage error: %v", err)
+
+	return skillserver.NewEchoResponse().
+		OutputSpeech("Sorry, something went wrong on our end, please try again later.")
+}
+
+// rateLimitedSpeech will tell the user when they should try again, based on the rate limit
+// reset time provided by Twitch if there is one.
+func rateLimitedSpeech(err error) string {
+	twitchErr, ok := err.(*twitch.Error)
+	if !ok || twitchErr.Reset.IsZero() {
+		return "Twitch is busy right now, please try again in a minute."
+	}
+
+	seconds := int(math.Ceil(time.Until(twitchErr.Reset).Seconds()))
+	if seconds <= 1 {
+		return "Twitch is busy right now, please try again."
+	} else if seconds > 60 {
+		return "Twitch is busy right now, please try again in a few minutes."
+	}
+
+	return fmt.Sprintf("Twitch is busy right now, please try again in %d seconds.", seconds)
+}
@@ -0,0 +1,50 @@
+package alexa
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rking788/twitch-box/twitch"
+)
+
+func TestErrorResponse(t *testing.T) {
+	cases := []struct {
+		err         error
+		linkAccount bool
+	}{
+		{&twitch.Error{Kind: twitch.ErrUnauthorized, StatusCode: 401}, true},
+		{&twitch.Error{Kind: twitch.ErrForbidden, StatusCode: 403}, true},
+		{&twitch.Error{Kind: twitch.ErrNotFound, StatusCode: 404}, false},
+		{&twitch.Error{Kind: twitch.ErrRateLimited, StatusCode: 429}, false},
+		{&twitch.Error{Kind: twitch.ErrUpstream, StatusCode: 503}, false},
+		{&twitch.Error{Kind: twitch.ErrDecode}, false},
+		{&twitch.Error{Kind: twitch.ErrNotLive, StatusCode: 404}, false},
+		{&twitch.Error{Kind: twitch.ErrGeoBlocked, StatusCode: 403}, false},
+		{&twitch.Error{Kind: twitch.ErrSubscriberOnly, StatusCode: 403}, false},
+		{errors.New("something else"), false},
+	}
+
+	for _, c := range cases {
+		response := ErrorResponse(c.err)
+		if response.Response.OutputSpeech == nil || response.Response.OutputSpeech.Text == "" {
+			t.Fatalf("No speech returned for error: %v", c.err)
+		}
+
+		hasLinkCard := response.Response.Card != nil && response.Response.Card.Type == "LinkAccount"
+		if hasLinkCard != c.linkAccount {
+			t.Fatalf("Incorrect LinkAccount card for error(%v): %v", c.err, hasLinkCard)
+		}
+	}
+}
+
+func TestErrorResponseNamesMissingScopes(t *testing.T) {
+	err := &twitch.Error{Kind: twitch.ErrForbidden, StatusCode: 403,
+		MissingScopes: []string{twitch.ScopeUserReadFollows}}
+
+	response := ErrorResponse(err)
+	card := response.Response.Card
+	if card == nil || card.Type != "LinkAccount" || !strings.Contains(card.Content, twitch.ScopeUserReadFollows) {
+		t.Fatalf("Expected a LinkAccount card naming the missing scope, got %+v", card)
+	}
+}
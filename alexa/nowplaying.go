@@ -0,0 +1,124 @@
+package alexa
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// WhatsPlaying will tell the user about the stream they are listening to, with a card showing
+// the stream's thumbnail. The stream is taken from the stream token in the device's
+// AudioPlayer state if it is valid, otherwise the current stream in the user's history is
+// used. The stream's details are loaded again so the title, game and viewers are up to date.
+func WhatsPlaying(request *Request) (response *skillserver.EchoResponse) {
+
+	channelID, vodID := playingFromAudioPlayer(request.Body)
+	if channelID == "" {
+		accessToken := request.Session.User.AccessToken
+		if accessToken == "" {
+			return linkAccountResponse()
+		}
+
+		tokenInfo, err := twitchClient.RequireToken(accessToken)
+		if err != nil {
+			glg.Errorf("Error validating the user's access token: %s", err.Error())
+			return ErrorResponse(err)
+		}
+
+		channelID, err = history.Current(tokenInfo.User().ID)
+		if err != nil {
+			glg.Errorf("Error loading the user's current stream: %s", err.Error())
+			return StorageErrorResponse(err)
+		}
+	}
+
+	if channelID == "" {
+		return skillserver.NewEchoResponse().
+			OutputSpeech("Nothing is playing right now. Say play to start one of your followed streams.")
+	} else if vodID != "" {
+		return nowPlayingVOD(vodID)
+	}
+
+	live, err := twitchClient.FindLiveStreams([]string{channelID})
+	if err != nil {
+		glg.Errorf("Error loading the stream for channel(%s): %s", channelID, err.Error())
+		return ErrorResponse(err)
+	} else if len(live.Data) == 0 {
+		return skillserver.NewEchoResponse().
+			OutputSpeech("The stream you were listening to has ended.")
+	}
+	stream := live.Data[0]
+
+	lines := []string{stream.Title}
+	if stream.GameName != "" {
+		lines = append(lines, stream.GameName)
+	}
+	lines = append(lines, plural(stream.ViewerCount, "viewer"))
+	if uptime := stream.Uptime(); uptime > 0 {
+		lines = append(lines, "Live for "+shortDuration(uptime))
+	}
+
+	thumbnail := thumbnailURL(stream.ThumbnailURL)
+	response = skillserver.NewEchoResponse()
+	response.OutputSpeech(nowPlayingSpeech(stream)).
+		StandardCard(stream.UserName, strings.Join(lines, "\n"), thumbnail, thumbnail)
+
+	return
+}
+
+// playingFromAudioPlayer will return the channel and past broadcast IDs from the stream token
+// in the AudioPlayer state of the raw request body. Empty strings are returned if there isn't
+// a valid token.
+func playingFromAudioPlayer(body []byte) (channelID, vodID string) {
+	state := ParseAudioPlayerState(body)
+	if state == nil || state.Token == "" {
+		return "", ""
+	}
+
+	token, err := streamTokens.Parse(state.Token)
+	if err != nil {
+		glg.Warnf("Ignoring AudioPlayer token for what's playing: %s", err.Error())
+		return "", ""
+	}
+
+	return token.ChannelID, token.VODID
+}
+
+// nowPlayingSpeech will describe the channel, game, title, viewers and uptime of the stream.
+func nowPlayingSpeech(stream *twitch.Stream) string {
+	speech := "You're listening to " + stream.UserName
+	if stream.GameName != "" {
+		speech += " playing " + stream.GameName
+	}
+	speech += "."
+	if stream.Title != "" {
+		speech += fmt.Sprintf(" The stream is called %s.", stream.Title)
+	}
+
+	if uptime := stream.Uptime(); uptime > 0 {
+		speech += fmt.Sprintf(" They have %s and have been live for %s.", plural(stream.ViewerCount, "viewer"),
+			spokenDuration(uptime))
+	} else {
+		speech += fmt.Sprintf(" They have %s.", plural(stream.ViewerCount, "viewer"))
+	}
+
+	return speech
+}
+
+// nowPlayingVOD will tell the user which past broadcast they are listening to.
+func nowPlayingVOD(vodID string) *skillserver.EchoResponse {
+
+	video, err := twitchClient.GetVideo(vodID)
+	if err != nil {
+		glg.Errorf("Error loading VOD(%s): %s", vodID, err.Error())
+		return ErrorResponse(err)
+	}
+
+	thumbnail := thumbnailURL(video.ThumbnailURL)
+	return skillserver.NewEchoResponse().
+		OutputSpeech(fmt.Sprintf("You're listening to a past broadcast from %s, %s.", video.UserName, video.Title)).
+		StandardCard(video.UserName, video.Title, thumbnail, thumbnail)
+}
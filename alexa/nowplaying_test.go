@@ -0,0 +1,88 @@
+package alexa
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rking788/twitch-box/twitch"
+)
+
+func TestWhatsPlaying(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	vodToken := NewStreamToken("4000", "audio_only")
+	vodToken.VODID = "100"
+
+	cases := []struct {
+		name         string
+		current      string
+		accessToken  string
+		playerToken  string
+		speech       string
+		thumbnailURL string
+	}{
+		{name: "History", current: "5000", accessToken: "token",
+			speech: "You're listening to Stranger playing Chess. The stream is called Stranger Title. " +
+				"They have 1234 viewers.",
+			thumbnailURL: "https://localhost/320x180.jpg"},
+		{name: "AudioPlayerToken", current: "2000", playerToken: signedTestToken("5000", "audio_only", 0),
+			speech: "You're listening to Stranger playing Chess."},
+		{name: "InvalidAudioPlayerToken", current: "5000", accessToken: "token", playerToken: "forged",
+			speech: "You're listening to Stranger"},
+		{name: "VOD", playerToken: streamTokens.Sign(vodToken),
+			speech:       "You're listening to a past broadcast from Sleepy, Last Night.",
+			thumbnailURL: "https://localhost/320x180.jpg"},
+		{name: "Ended", current: "4000", accessToken: "token", speech: "has ended"},
+		{name: "NothingPlaying", accessToken: "token", speech: "Nothing is playing"},
+		{name: "NotLinked", speech: "needs to be linked"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			env.history.Clear("1000")
+			if c.current != "" {
+				env.history.Push("1000", c.current)
+			}
+
+			request := newTestIntentRequest("WhatsPlaying")
+			request.Session.User.AccessToken = c.accessToken
+			if c.playerToken != "" {
+				request.Body = playbackControllerRequestBody(IntentRequestType, c.accessToken, c.playerToken)
+			}
+
+			response := WhatsPlaying(request)
+			if speech := responseSpeech(response); !strings.Contains(speech, c.speech) {
+				t.Fatalf("Expected speech containing %q, got %q", c.speech, speech)
+			}
+			if c.thumbnailURL == "" {
+				return
+			}
+			if card := response.Response.Card; card == nil ||
+				card.Image.LargeImageURL != c.thumbnailURL {
+				t.Fatalf("Expected a card with the stream's thumbnail: %+v", card)
+			}
+		})
+	}
+}
+
+// unavailableHistoryStore is a HistoryStore whose backing store can't be reached.
+type unavailableHistoryStore struct {
+	*twitch.MemoryHistoryStore
+}
+
+func (unavailableHistoryStore) Current(userID string) (string, error) {
+	return "", errors.New("connection refused")
+}
+
+func TestWhatsPlayingHistoryUnavailable(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+	history = unavailableHistoryStore{env.history}
+
+	response := WhatsPlaying(newTestIntentRequest("WhatsPlaying"))
+	if speech := responseSpeech(response); speech != "Sorry, something went wrong on our end, please try again later." {
+		t.Fatalf("Expected a storage failure not to be blamed on Twitch, got %q", speech)
+	}
+}
@@ -0,0 +1,63 @@
+package alexa
+
+import (
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// The PlaybackController request types sent when a hardware button (e.g. on an Echo remote
+// or Fire TV) is pressed while the skill is playing.
+const (
+	NextCommandIssued     = "PlaybackController.NextCommandIssued"
+	PreviousCommandIssued = "PlaybackController.PreviousCommandIssued"
+	PlayCommandIssued     = "PlaybackController.PlayCommandIssued"
+	PauseCommandIssued    = "PlaybackController.PauseCommandIssued"
+)
+
+// PlaybackControllerHandler will respond to the PlaybackController requests by using the same
+// next, previous and resume logic as the matching built in intents. These requests don't
+// have a session, the user's access token is decoded from the context in the raw body instead.
+func PlaybackControllerHandler(request *Request) (response *skillserver.EchoResponse) {
+
+	controllerRequest, err := ParseAudioPlayerRequest(request.Body)
+	if err != nil {
+		glg.Errorf("Failed to decode PlaybackController request: %s", err.Error())
+		return skillserver.NewEchoResponse()
+	}
+
+	requestType := controllerRequest.Request.Type
+	user := controllerRequest.Context.System.User
+	glg.Infof("%s: user=%s", requestType, user.UserID)
+
+	// Only the AudioPlayer interface can be controlled by these requests, so the stream is
+	// always audio only.
+	streamRequest := &streamRequest{accessToken: user.AccessToken, keepSource: true}
+	switch requestType {
+	case NextCommandIssued:
+		streamRequest.command = twitch.NEXT
+	case PreviousCommandIssued:
+		streamRequest.command = twitch.PREVIOUS
+	case PlayCommandIssued:
+		streamRequest.command = twitch.RESUME
+		streamRequest.resumeChannelID = resumeChannelID(request.Body)
+	case PauseCommandIssued:
+		return directivesOnly(StopAudioDirective())
+	default:
+		glg.Warnf("Unhandled PlaybackController request type: %s", requestType)
+		return skillserver.NewEchoResponse()
+	}
+
+	return directivesOnly(startStream(streamRequest))
+}
+
+// directivesOnly will remove everything but the directives from the response. Responses to
+// PlaybackController requests can't include speech, cards, reprompts or end the session.
+func directivesOnly(response *skillserver.EchoResponse) *skillserver.EchoResponse {
+	directives := response.Response.Directives
+
+	response = skillserver.NewEchoResponse()
+	response.Response.Directives = directives
+
+	return response
+}
@@ -0,0 +1,80 @@
+package alexa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+func playbackControllerRequestBody(requestType, accessToken, audioPlayerToken string) []byte {
+	return []byte(fmt.Sprintf(`{
+	"context": {
+		"System": {"user": {"userId": "amzn1.ask.account.test", "accessToken": %q}},
+		"AudioPlayer": {"token": %q, "offsetInMilliseconds": 0, "playerActivity": "PAUSED"}
+	},
+	"request": {"type": %q, "requestId": "id", "timestamp": "2017-12-01T12:00:00Z"}
+}`, accessToken, audioPlayerToken, requestType))
+}
+
+func TestPlaybackControllerHandler(t *testing.T) {
+	cases := []struct {
+		requestType string
+		expected    string
+	}{
+		{NextCommandIssued, "3000"},
+		{PreviousCommandIssued, "2000"},
+		{PlayCommandIssued, "3000"},
+	}
+
+	env := newTestEnv(t)
+	defer env.Close()
+	env.play("1000", "2000")
+
+	for _, c := range cases {
+		t.Run(c.requestType, func(t *testing.T) {
+			token := streamTokens.Sign(NewStreamToken("3000", "audio_only"))
+			response := PlaybackControllerHandler(testRequest(playbackControllerRequestBody(c.requestType, "token", token)))
+			validateDirectivesOnly(t, response)
+
+			if len(response.Response.Directives) != 1 {
+				t.Fatalf("Expected a single play directive: %+v", response.Response.Directives)
+			}
+			directive := response.Response.Directives[0].(*skillserver.AudioDirective)
+			streamToken, err := streamTokens.Parse(directive.AudioItem.Stream.Token)
+			if err != nil || streamToken.ChannelID != c.expected {
+				t.Fatalf("Expected channel %s to play: %+v (err=%v)", c.expected, streamToken, err)
+			}
+		})
+	}
+}
+
+func TestPlaybackControllerHandlerPause(t *testing.T) {
+	response := PlaybackControllerHandler(testRequest(playbackControllerRequestBody(PauseCommandIssued, "token", "")))
+	validateDirectivesOnly(t, response)
+
+	if len(response.Response.Directives) != 1 ||
+		response.Response.Directives[0].(*skillserver.AudioDirective).Type != "AudioPlayer.Stop" {
+		t.Fatalf("Expected a stop directive: %+v", response.Response.Directives)
+	}
+}
+
+func TestPlaybackControllerHandlerErrorsAreSilent(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	// Nothing has been played yet so there is nothing previous to play
+	response := PlaybackControllerHandler(testRequest(playbackControllerRequestBody(PreviousCommandIssued, "token", "")))
+	validateDirectivesOnly(t, response)
+
+	// Without a linked account the response would normally include a link account card
+	response = PlaybackControllerHandler(testRequest(playbackControllerRequestBody(NextCommandIssued, "", "")))
+	validateDirectivesOnly(t, response)
+}
+
+func validateDirectivesOnly(t *testing.T, response *skillserver.EchoResponse) {
+	body := response.Response
+	if body.OutputSpeech != nil || body.Card != nil || body.Reprompt != nil || body.ShouldEndSession != nil {
+		t.Fatalf("PlaybackController responses can only include directives: %+v", body)
+	}
+}
@@ -0,0 +1,124 @@
+package alexa
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/matcher"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// GameSlot is the slot in the PlayCategory intent with the spoken game or category name.
+const GameSlot = "Game"
+
+// categoryMatcher scores spoken game names against the names of the categories found by the
+// category search.
+var categoryMatcher = matcher.New()
+
+// PlayCategory will play a live stream in the game or category named in the request. The
+// user's followed channels that are streaming the game are played first, then the most
+// watched streams for the game. Next and previous stay in the category until the user asks
+// to play something else.
+func PlayCategory(request *Request) (response *skillserver.EchoResponse) {
+
+	intent := request.Request.Intent
+	slot := intent.Slots[GameSlot]
+	if strings.TrimSpace(slot.Value) == "" {
+		return skillserver.NewEchoResponse().
+			OutputSpeech("Which game would you like to listen to?").
+			ElicitSlot(GameSlot, copyIntent(intent))
+	}
+
+	streamRequest := newStreamRequest(request, twitch.PLAY)
+	if streamRequest.accessToken == "" {
+		return linkAccountResponse()
+	}
+
+	game, err := resolveCategory(slot.Value)
+	if err != nil {
+		glg.Errorf("Error resolving category(%s): %s", slot.Value, err.Error())
+		return ErrorResponse(err)
+	} else if game == nil {
+		return skillserver.NewEchoResponse().
+			OutputSpeech(fmt.Sprintf("Sorry, I couldn't find a game called %s.", slot.Value))
+	}
+	glg.Infof("Resolved category(%s) to %s(%s)", slot.Value, game.Name, game.ID)
+
+	streamRequest.source = &twitch.QueueSource{GameID: game.ID, GameName: game.Name}
+
+	return startStream(streamRequest)
+}
+
+// resolveCategory will find the game or category for the spoken name with the category
+// search, falling back to a game with exactly the spoken name. nil is returned if nothing
+// matched.
+func resolveCategory(spoken string) (*twitch.Game, error) {
+
+	games, err := twitchClient.SearchCategories(spoken)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]matcher.Candidate, 0, len(games))
+	gamesByID := make(map[string]*twitch.Game, len(games))
+	for _, game := range games {
+		candidates = append(candidates, matcher.Candidate{ID: game.ID, Names: []string{game.Name}})
+		gamesByID[game.ID] = game
+	}
+
+	matches := categoryMatcher.Rank(spoken, candidates)
+	switch {
+	case len(matches) > 0:
+		return gamesByID[matches[0].Candidate.ID], nil
+	case len(games) > 0:
+		// Twitch found something even if the names don't look alike, e.g. "gta"
+		return games[0], nil
+	}
+
+	game, err := twitchClient.GetGameByName(spoken)
+	if twitch.KindOf(err) == twitch.ErrNotFound {
+		return nil, nil
+	}
+
+	return game, err
+}
+
+// loadCategoryStreams will load the live streams in the category, the user's followed
+// channels in the category first and then the most watched streams. Failing to load the
+// followed channels is only logged, unless the user's token was rejected.
+func loadCategoryStreams(accessToken string, user *twitch.User, gameID string) (*twitch.StreamsResponse, error) {
+
+	streams := make([]*twitch.Stream, 0)
+	follows, err := twitchClient.GetLiveFollows(accessToken, user)
+	if err != nil && !twitch.IsPartial(err) {
+		if twitch.KindOf(err) == twitch.ErrUnauthorized {
+			return nil, err
+		}
+		glg.Warnf("Only playing top streams for category(%s), failed to load follows: %s", gameID, err.Error())
+	} else {
+		for _, stream := range follows.Data {
+			if stream.GameID == gameID {
+				streams = append(streams, stream)
+			}
+		}
+	}
+
+	top, err := twitchClient.GetTopStreams(&twitch.StreamsFilter{GameIDs: []string{gameID}})
+	if err != nil && len(streams) > 0 {
+		glg.Warnf("Only playing followed streams for category(%s), failed to load top streams: %s",
+			gameID, err.Error())
+		return &twitch.StreamsResponse{Data: streams}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, stream := range top.Data {
+		if findStream(streams, stream.UserID) == nil {
+			streams = append(streams, stream)
+		}
+	}
+
+	return &twitch.StreamsResponse{Data: streams}, nil
+}
@@ -0,0 +1,121 @@
+package alexa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+// newPlayCategoryRequest will create a PlayCategory request for the spoken game name.
+func newPlayCategoryRequest(game string) *Request {
+	request := newTestIntentRequest("PlayCategory")
+	request.Request.Intent.Slots = map[string]skillserver.EchoSlot{
+		GameSlot: {Name: GameSlot, Value: game},
+	}
+
+	return request
+}
+
+func TestPlayCategory(t *testing.T) {
+	cases := []struct {
+		name      string
+		game      string
+		speech    string
+		directive string
+		current   string
+	}{
+		{name: "Followed", game: "minecraft", speech: "Starting stream for Streamer",
+			directive: "AudioPlayer.Play", current: "2000"},
+		{name: "TopStreams", game: "chess", speech: "Starting stream for Stranger",
+			directive: "AudioPlayer.Play", current: "5000"},
+		{name: "NobodyStreaming", game: "tetris", speech: "nobody is streaming Tetris"},
+		{name: "NotFound", game: "nothing", speech: "couldn't find a game called nothing"},
+		{name: "MissingGame", speech: "Which game", directive: "Dialog.ElicitSlot"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			defer env.Close()
+
+			response := PlayCategory(newPlayCategoryRequest(c.game))
+			if speech := responseSpeech(response); !strings.Contains(speech, c.speech) {
+				t.Fatalf("Expected speech containing %q, got %q", c.speech, speech)
+			}
+			if directive := directiveType(response); directive != c.directive {
+				t.Fatalf("Expected directive %q, got %q", c.directive, directive)
+			}
+
+			queue, _ := env.queues.Queue("1000")
+			if queue.Current() != c.current {
+				t.Fatalf("Expected the current stream to be %q, got %q", c.current, queue.Current())
+			}
+		})
+	}
+}
+
+func TestPlayCategoryNextStaysInCategory(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	PlayCategory(newPlayCategoryRequest("minecraft"))
+
+	steps := []struct {
+		intentName string
+		speech     string
+	}{
+		{"AMAZON.NextIntent", "Starting stream for Crafter"},
+		{"AMAZON.NextIntent", "Starting stream for Streamer"},
+		{"AMAZON.PreviousIntent", "Starting stream for Crafter"},
+		{"AMAZON.ResumeIntent", "Starting stream for Crafter"},
+		// Asking to play without a category goes back to the followed channels
+		{"StartAudioStream", "Starting stream for Streamer"},
+		{"AMAZON.NextIntent", "Starting stream for Other"},
+	}
+
+	router := NewSkillRouter()
+	for _, step := range steps {
+		response := router.Route(newTestIntentRequest(step.intentName))
+		if speech := responseSpeech(response); speech != step.speech {
+			t.Fatalf("%s: expected %q, got %q", step.intentName, step.speech, speech)
+		}
+	}
+
+	queue, _ := env.queues.Queue("1000")
+	if queue.Source != nil {
+		t.Fatalf("Expected the queue to be back on the followed channels: %+v", queue.Source)
+	}
+}
+
+func TestPlaybackControllerStaysInCategory(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	PlayCategory(newPlayCategoryRequest("minecraft"))
+
+	request := newTestIntentRequest("")
+	request.Body = playbackControllerRequestBody(NextCommandIssued, "token", "")
+	PlaybackControllerHandler(request)
+
+	queue, _ := env.queues.Queue("1000")
+	if queue.Current() != "7000" || queue.Source == nil || queue.Source.GameName != "Minecraft" {
+		t.Fatalf("Expected the next button to stay in the category: current=%s source=%+v",
+			queue.Current(), queue.Source)
+	}
+}
+
+func TestResolveCategory(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	game, err := resolveCategory("minecraft")
+	if err != nil || game == nil || game.ID != "100" {
+		t.Fatalf("Expected the exact category over partial matches (%v): %+v", err, game)
+	}
+
+	game, err = resolveCategory("nothing")
+	if err != nil || game != nil {
+		t.Fatalf("Expected no category (%v): %+v", err, game)
+	}
+}
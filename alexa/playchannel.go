@@ -0,0 +1,371 @@
+package alexa
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/matcher"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// ChannelSlot is the slot in the PlayChannel intent with the spoken channel name.
+const ChannelSlot = "Channel"
+
+// channelChoicesAttribute is the session attribute that keeps the channels the user was asked
+// to choose between because the spoken name matched them equally well.
+const channelChoicesAttribute = "channelChoices"
+
+// channelMatch is the channel a spoken channel name was resolved to.
+type channelMatch struct {
+	ID          string
+	Login       string
+	DisplayName string
+	// Certain is false if the spoken name was too far from the channel's names, or too close
+	// to another channel's names, the user should confirm the channel before it is played.
+	Certain bool
+	// Choices are the channels, including this one, that matched the spoken name too closely
+	// to pick one. Empty unless there are at least two.
+	Choices []*channelMatch
+}
+
+// PlayChannel will play the live stream for the channel named in the request. The spoken
+// name is resolved against the user's followed channels first, then the Twitch channel
+// search. If the name matched several channels equally well the user is asked to choose
+// between them, otherwise the user is asked to confirm the channel if the match isn't
+// certain. If the channel isn't live, the user is offered the channel's latest past
+// broadcast instead.
+func PlayChannel(request *Request) (response *skillserver.EchoResponse) {
+
+	intent := request.Request.Intent
+	slot := intent.Slots[ChannelSlot]
+	if strings.TrimSpace(slot.Value) == "" {
+		return skillserver.NewEchoResponse().
+			OutputSpeech("Which channel would you like to play?").
+			ElicitSlot(ChannelSlot, copyIntent(intent))
+	}
+
+	streamRequest := newStreamRequest(request, twitch.PLAY)
+	if streamRequest.accessToken == "" {
+		return linkAccountResponse()
+	}
+
+	tokenInfo, err := twitchClient.RequireToken(streamRequest.accessToken)
+	if err != nil {
+		glg.Errorf("Error validating the user's access token: %s", err.Error())
+		return ErrorResponse(err)
+	}
+	user := tokenInfo.User()
+
+	match := chosenChannel(request, slot.Value)
+	if match == nil {
+		match, err = resolveChannel(streamRequest.accessToken, user, slot.Value)
+	}
+	if err != nil {
+		glg.Errorf("Error resolving channel(%s): %s", slot.Value, err.Error())
+		return ErrorResponse(err)
+	} else if match == nil {
+		return skillserver.NewEchoResponse().
+			OutputSpeech(fmt.Sprintf("Sorry, I couldn't find a channel called %s.", slot.Value))
+	}
+	glg.Infof("Resolved channel(%s) to %s, certain=%t", slot.Value, match.Login, match.Certain)
+
+	if !match.Certain && slot.ConfirmationStatus == skillserver.ConfirmationDenied {
+		return skillserver.NewEchoResponse().
+			OutputSpeech("Sorry about that, which channel would you like to play?").
+			ElicitSlot(ChannelSlot, copyIntent(intent))
+	} else if !match.Certain && len(match.Choices) > 1 {
+		return chooseChannelResponse(intent, match.Choices)
+	} else if !match.Certain && slot.ConfirmationStatus != skillserver.ConfirmationConfirmed {
+		return skillserver.NewEchoResponse().
+			OutputSpeech(fmt.Sprintf("Did you mean %s?", match.DisplayName)).
+			ConfirmSlot(ChannelSlot, matchedIntent(intent, match))
+	}
+
+	liveStreams := loadLiveFollowsForQueue(streamRequest.accessToken, user)
+	stream := findStream(liveStreams, match.ID)
+	if stream == nil {
+		live, err := twitchClient.FindLiveStreams([]string{match.ID})
+		if err != nil {
+			glg.Errorf("Error checking if channel(%s) is live: %s", match.Login, err.Error())
+			return ErrorResponse(err)
+		}
+		if len(live.Data) > 0 {
+			stream = live.Data[0]
+			liveStreams = append(liveStreams, stream)
+		}
+	}
+
+	if stream == nil {
+		return offerLatestVOD(streamRequest, match, intent)
+	}
+
+	// Resuming the channel after jumping to it always chooses the channel, the queue goes back
+	// to the followed channels so next and previous continue from it.
+	response = skillserver.NewEchoResponse()
+	changes := &twitch.QueueChanges{SetSource: true, JumpTo: match.ID, CountGamePlay: true}
+	twitch.FindStreamForCommand(queues, user, liveStreams, twitch.RESUME, changes, response)
+
+	return playStream(streamRequest, user, stream, response)
+}
+
+// channelMatcher scores spoken channel names against the logins and display names of channels.
+var channelMatcher = matcher.New()
+
+// resolveChannel will find the channel for the spoken name. The user's followed channels are
+// checked first, then the Twitch channel search. A certain match from the search is preferred
+// over an uncertain match from the followed channels. nil is returned if no channel matched.
+func resolveChannel(accessToken string, user *twitch.User, spoken string) (*channelMatch, error) {
+
+	var followedMatch *channelMatch
+	followed, err := twitchClient.GetFollowedChannels(accessToken, user)
+	if twitch.KindOf(err) == twitch.ErrUnauthorized {
+		return nil, err
+	} else if err != nil && !twitch.IsPartial(err) {
+		glg.Warnf("Only searching for channel(%s), failed to load followed channels: %s", spoken, err.Error())
+	} else {
+		followedMatch = matchChannels(spoken, followedChannelMatches(followed.Data))
+		if followedMatch != nil && followedMatch.Certain {
+			return followedMatch, nil
+		}
+	}
+
+	results, err := twitchClient.SearchChannels(spoken)
+	if err != nil && followedMatch != nil {
+		glg.Warnf("Using followed channel for %s, failed to search channels: %s", spoken, err.Error())
+		return followedMatch, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	searchMatches := searchChannelMatches(results)
+	searchMatch := matchChannels(spoken, searchMatches)
+	switch {
+	case searchMatch != nil && searchMatch.Certain:
+		return searchMatch, nil
+	case followedMatch != nil && searchMatch != nil:
+		// Neither is certain, so rank them together to find the channels the user should
+		// choose between. The followed channels come first when the names match equally.
+		return matchChannels(spoken, append(followedChannelMatches(followed.Data), searchMatches...)), nil
+	case followedMatch != nil:
+		return followedMatch, nil
+	case searchMatch != nil:
+		return searchMatch, nil
+	case len(searchMatches) > 0:
+		// Twitch found something even if the names don't look alike, e.g. a nickname
+		return searchMatches[0], nil
+	}
+
+	return nil, nil
+}
+
+// matchChannels will choose the channel that best matches the spoken name. If the best match
+// isn't certain, the channels that matched as closely are kept as the match's choices. nil is
+// returned if none of the channels matched.
+func matchChannels(spoken string, channels []*channelMatch) *channelMatch {
+
+	candidates := make([]matcher.Candidate, 0, len(channels))
+	channelsByID := make(map[string]*channelMatch, len(channels))
+	for _, channel := range channels {
+		if _, ok := channelsByID[channel.ID]; ok {
+			continue
+		}
+		candidates = append(candidates, matcher.Candidate{ID: channel.ID,
+			Names: []string{channel.Login, channel.DisplayName}})
+		channelsByID[channel.ID] = channel
+	}
+
+	matches := channelMatcher.Rank(spoken, candidates)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	match := *channelsByID[matches[0].Candidate.ID]
+	match.Certain = channelMatcher.Certain(matches)
+	if ambiguous := channelMatcher.Ambiguous(matches); !match.Certain && len(ambiguous) > 1 {
+		if len(ambiguous) > StreamChoicesPageSize {
+			ambiguous = ambiguous[:StreamChoicesPageSize]
+		}
+		for _, choice := range ambiguous {
+			match.Choices = append(match.Choices, channelsByID[choice.Candidate.ID])
+		}
+	}
+
+	return &match
+}
+
+// chooseChannelResponse will ask the user which of the channels they meant. The choices are
+// kept in the session so the reply can be matched against them.
+func chooseChannelResponse(intent skillserver.EchoIntent, channels []*channelMatch) *skillserver.EchoResponse {
+
+	names := make([]string, 0, len(channels))
+	choices := make([]streamChoice, 0, len(channels))
+	for _, channel := range channels {
+		names = append(names, channel.DisplayName)
+		choices = append(choices, streamChoice{ID: channel.ID, Login: channel.Login, Name: channel.DisplayName})
+	}
+	speech := fmt.Sprintf("Did you mean %s or %s?", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+
+	updated := copyIntent(intent)
+	slot := updated.Slots[ChannelSlot]
+	slot.Name = ChannelSlot
+	slot.Value = ""
+	updated.Slots[ChannelSlot] = slot
+
+	response := skillserver.NewEchoResponse().
+		OutputSpeech(speech).
+		ElicitSlot(ChannelSlot, updated)
+	SetSessionAttribute(response, channelChoicesAttribute, choices)
+
+	return response
+}
+
+// chosenChannel will find the channel the user chose after being asked to choose between the
+// channels matching an ambiguous name. nil is returned if the user wasn't asked to choose or
+// the reply doesn't match any of the choices.
+func chosenChannel(request *Request, spoken string) *channelMatch {
+
+	choices := []streamChoice{}
+	if !request.SessionAttribute(channelChoicesAttribute, &choices) {
+		return nil
+	}
+
+	channels := make([]*channelMatch, 0, len(choices))
+	for _, choice := range choices {
+		channels = append(channels, &channelMatch{ID: choice.ID, Login: choice.Login, DisplayName: choice.Name})
+	}
+
+	match := matchChannels(spoken, channels)
+	if match == nil {
+		return nil
+	}
+	// The user already chose between these channels so the best match doesn't need confirming
+	match.Certain = true
+	match.Choices = nil
+
+	return match
+}
+
+// followedChannelMatches will convert the followed channels into uncertain channel matches.
+func followedChannelMatches(channels []*twitch.FollowedChannel) []*channelMatch {
+	matches := make([]*channelMatch, 0, len(channels))
+	for _, channel := range channels {
+		matches = append(matches, &channelMatch{ID: channel.BroadcasterID, Login: channel.BroadcasterLogin,
+			DisplayName: channel.BroadcasterName})
+	}
+
+	return matches
+}
+
+// searchChannelMatches will convert the channel search results into uncertain channel matches.
+func searchChannelMatches(channels []*twitch.Channel) []*channelMatch {
+	matches := make([]*channelMatch, 0, len(channels))
+	for _, channel := range channels {
+		matches = append(matches, &channelMatch{ID: channel.ID, Login: channel.BroadcasterLogin,
+			DisplayName: channel.DisplayName})
+	}
+
+	return matches
+}
+
+// loadLiveFollowsForQueue will load the user's live follows so the played channel can be
+// placed in the user's playback queue. Any errors are only logged since the channel can
+// still be played without them.
+func loadLiveFollowsForQueue(accessToken string, user *twitch.User) []*twitch.Stream {
+	liveStreams, err := twitchClient.GetLiveFollows(accessToken, user)
+	if err != nil && !twitch.IsPartial(err) {
+		glg.Warnf("Failed to load live follows for the playback queue: %s", err.Error())
+		return []*twitch.Stream{}
+	}
+
+	return liveStreams.Data
+}
+
+// findStream will return the stream for the channel with the user ID, or nil if the channel
+// isn't in the list.
+func findStream(streams []*twitch.Stream, userID string) *twitch.Stream {
+	for _, stream := range streams {
+		if stream.UserID == userID {
+			return stream
+		}
+	}
+
+	return nil
+}
+
+// offerLatestVOD will tell the user the channel isn't live and ask if they would like to play
+// the channel's latest past broadcast instead. Once the user confirms the intent, the past
+// broadcast is played.
+func offerLatestVOD(request *streamRequest, match *channelMatch, intent skillserver.EchoIntent) *skillserver.EchoResponse {
+
+	offline := fmt.Sprintf("%s isn't live right now.", match.DisplayName)
+	if intent.ConfirmationStatus == skillserver.ConfirmationDenied {
+		return skillserver.NewEchoResponse().OutputSpeech("Okay, maybe next time.")
+	}
+
+	video, err := twitchClient.GetLatestVOD(match.ID)
+	if err != nil {
+		if twitch.KindOf(err) != twitch.ErrNotFound {
+			glg.Errorf("Error loading the latest VOD for %s: %s", match.Login, err.Error())
+		}
+		return skillserver.NewEchoResponse().OutputSpeech(offline)
+	}
+
+	if intent.ConfirmationStatus == skillserver.ConfirmationConfirmed {
+		return playVOD(request, match, video)
+	}
+
+	return skillserver.NewEchoResponse().
+		OutputSpeech(fmt.Sprintf("%s Would you like to play their latest broadcast, %s?", offline, video.Title)).
+		ConfirmIntent(intent.Name, matchedIntent(intent, match))
+}
+
+// playVOD will respond with the directive to play the past broadcast, a video stream is used
+// if the request's device supports video playback.
+func playVOD(request *streamRequest, match *channelMatch, video *twitch.Video) (response *skillserver.EchoResponse) {
+
+	selection, err := twitchClient.GetVODStream(video.ID, request.variantConstraints())
+	if err != nil {
+		glg.Errorf("Error loading VOD(%s) stream variant: %s", video.ID, err.Error())
+		return ErrorResponse(err)
+	}
+	streamVariant := selection.Selected
+
+	response = skillserver.NewEchoResponse()
+	response.OutputSpeech(fmt.Sprintf("Starting the latest broadcast from %s", match.DisplayName))
+	if streamVariant.AudioOnly {
+		token := NewStreamToken(match.ID, streamVariant.Name)
+		token.VODID = video.ID
+		response.AppendAudioDirective(NewAudioDirectiveWithStreamURL(streamVariant.URI, streamTokens.Sign(token)))
+		thumbnail := thumbnailURL(video.ThumbnailURL)
+		response.StandardCard(match.DisplayName, video.Title, thumbnail, thumbnail)
+	} else {
+		response.AppendVideoDirective(NewVideoDirectiveWithStreamURL(streamVariant.URI, video.Title, match.DisplayName))
+	}
+
+	return
+}
+
+// copyIntent will copy the intent so the slots can be updated without changing the request.
+func copyIntent(intent skillserver.EchoIntent) *skillserver.EchoIntent {
+	updated := intent
+	updated.Slots = make(map[string]skillserver.EchoSlot, len(intent.Slots))
+	for name, slot := range intent.Slots {
+		updated.Slots[name] = slot
+	}
+
+	return &updated
+}
+
+// matchedIntent will copy the intent with the channel slot set to the matched channel's
+// name, so the match is exact when the intent is sent back after the user confirms it.
+func matchedIntent(intent skillserver.EchoIntent, match *channelMatch) *skillserver.EchoIntent {
+	updated := copyIntent(intent)
+	slot := updated.Slots[ChannelSlot]
+	slot.Name = ChannelSlot
+	slot.Value = match.DisplayName
+	updated.Slots[ChannelSlot] = slot
+
+	return updated
+}
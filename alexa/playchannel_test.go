@@ -0,0 +1,148 @@
+package alexa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+// directiveType will return the type of the first directive in the response, or the empty
+// string if there aren't any directives.
+func directiveType(response *skillserver.EchoResponse) string {
+	if len(response.Response.Directives) == 0 {
+		return ""
+	}
+
+	switch directive := response.Response.Directives[0].(type) {
+	case *skillserver.AudioDirective:
+		return directive.Type
+	case *skillserver.DialogDirective:
+		return string(directive.Type)
+	case *skillserver.VideoDirective:
+		return directive.Type
+	}
+
+	return ""
+}
+
+// newPlayChannelRequest will create a PlayChannel request for the spoken channel name with
+// the provided slot and intent confirmation statuses.
+func newPlayChannelRequest(channel string, slotStatus, intentStatus skillserver.ConfirmationStatus) *Request {
+	request := newTestIntentRequest("PlayChannel")
+	request.Request.Intent.ConfirmationStatus = intentStatus
+	request.Request.Intent.Slots = map[string]skillserver.EchoSlot{
+		ChannelSlot: {Name: ChannelSlot, Value: channel, ConfirmationStatus: slotStatus},
+	}
+
+	return request
+}
+
+func TestPlayChannel(t *testing.T) {
+	cases := []struct {
+		name         string
+		channel      string
+		slotStatus   skillserver.ConfirmationStatus
+		intentStatus skillserver.ConfirmationStatus
+		speech       string
+		directive    string
+		current      string
+	}{
+		{name: "Followed", channel: "streamer", speech: "Starting stream for Streamer",
+			directive: "AudioPlayer.Play", current: "2000"},
+		{name: "FollowedSpoken", channel: "the stream er", speech: "Starting stream for Streamer",
+			directive: "AudioPlayer.Play", current: "2000"},
+		{name: "FollowedPartial", channel: "stream", speech: "Did you mean Streamer?",
+			directive: "Dialog.ConfirmSlot"},
+		{name: "FollowedPartialConfirmed", channel: "stream", slotStatus: skillserver.ConfirmationConfirmed,
+			speech: "Starting stream for Streamer", directive: "AudioPlayer.Play", current: "2000"},
+		{name: "FollowedPartialDenied", channel: "stream", slotStatus: skillserver.ConfirmationDenied,
+			speech: "which channel would you like to play", directive: "Dialog.ElicitSlot"},
+		{name: "Search", channel: "Stranger", speech: "Starting stream for Stranger",
+			directive: "AudioPlayer.Play", current: "5000"},
+		{name: "SearchPartial", channel: "strange", speech: "Did you mean Stranger?",
+			directive: "Dialog.ConfirmSlot"},
+		{name: "NotFound", channel: "nobody", speech: "couldn't find a channel called nobody"},
+		{name: "MissingChannel", speech: "Which channel", directive: "Dialog.ElicitSlot"},
+		{name: "Offline", channel: "sleepy", speech: "Would you like to play their latest broadcast, Last Night?",
+			directive: "Dialog.ConfirmIntent"},
+		{name: "OfflineConfirmed", channel: "sleepy", intentStatus: skillserver.ConfirmationConfirmed,
+			speech: "Starting the latest broadcast from Sleepy", directive: "AudioPlayer.Play"},
+		{name: "OfflineDenied", channel: "sleepy", intentStatus: skillserver.ConfirmationDenied,
+			speech: "maybe next time"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			defer env.Close()
+
+			response := PlayChannel(newPlayChannelRequest(c.channel, c.slotStatus, c.intentStatus))
+			if speech := responseSpeech(response); !strings.Contains(speech, c.speech) {
+				t.Fatalf("Expected speech containing %q, got %q", c.speech, speech)
+			}
+			if directive := directiveType(response); directive != c.directive {
+				t.Fatalf("Expected directive %q, got %q", c.directive, directive)
+			}
+
+			queue, _ := env.queues.Queue("1000")
+			if c.current != "" && queue.Current() != c.current {
+				t.Fatalf("Expected channel %s to be the current stream, got %q", c.current, queue.Current())
+			}
+		})
+	}
+}
+
+func TestPlayChannelConfirmationUsesMatchedName(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	response := PlayChannel(newPlayChannelRequest("stream", "", ""))
+	directive, ok := response.Response.Directives[0].(*skillserver.DialogDirective)
+	if !ok || directive.SlotToConfirm != ChannelSlot {
+		t.Fatalf("Expected the channel slot to be confirmed: %+v", response.Response.Directives)
+	}
+
+	if value := directive.UpdatedIntent.Slots[ChannelSlot].Value; value != "Streamer" {
+		t.Fatalf("Expected the matched channel name in the updated intent, got %q", value)
+	}
+}
+
+func TestPlayChannelAmbiguous(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	response := PlayChannel(newPlayChannelRequest("strainer", "", ""))
+	if speech := responseSpeech(response); speech != "Did you mean Stranger or Streamer?" {
+		t.Fatalf("Expected to choose between the close matches, got %q", speech)
+	}
+	if directive := directiveType(response); directive != "Dialog.ElicitSlot" {
+		t.Fatalf("Expected the channel slot to be elicited, got %q", directive)
+	}
+
+	response = PlayChannel(nextTurn(t, response, "PlayChannel", map[string]string{ChannelSlot: "streamers"}))
+	if speech := responseSpeech(response); speech != "Starting stream for Streamer" {
+		t.Fatalf("Expected the chosen channel to be played without confirming, got %q", speech)
+	}
+
+	queue, _ := env.queues.Queue("1000")
+	if queue.Current() != "2000" {
+		t.Fatalf("Expected the chosen channel to be the current stream, got %q", queue.Current())
+	}
+}
+
+func TestPlayChannelVODToken(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	response := PlayChannel(newPlayChannelRequest("sleepy", "", skillserver.ConfirmationConfirmed))
+	directive := response.Response.Directives[0].(*skillserver.AudioDirective)
+	token, err := streamTokens.Parse(directive.AudioItem.Stream.Token)
+	if err != nil || token.ChannelID != "4000" || token.VODID != "100" {
+		t.Fatalf("Expected a token for the past broadcast(%v): %+v", err, token)
+	}
+
+	if response.Response.Card == nil || response.Response.Card.Image.SmallImageURL != "https://localhost/320x180.jpg" {
+		t.Fatalf("Expected a card with the past broadcast's thumbnail: %+v", response.Response.Card)
+	}
+}
@@ -0,0 +1,68 @@
+package alexa
+
+import (
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+// questionAttribute is the session attribute that records the yes or no question the user was
+// last asked, so their answer is sent to the handler for that question.
+const questionAttribute = "question"
+
+// The yes or no questions the user can be asked.
+const (
+	// welcomeQuestion asks if the user would like to play one of their followed streams.
+	welcomeQuestion = "welcome"
+	// browseQuestion asks if the user would like to play one of the most watched live streams.
+	browseQuestion = "browse"
+)
+
+// askQuestion will keep the question in the response's session attributes until the user
+// answers it.
+func askQuestion(response *skillserver.EchoResponse, question string) {
+	SetSessionAttribute(response, questionAttribute, question)
+}
+
+// Yes will answer yes to the question the user was last asked. Without a recorded question
+// the user is asked what they would like to do.
+func Yes(request *Request) *skillserver.EchoResponse {
+
+	question := ""
+	request.SessionAttribute(questionAttribute, &question)
+	switch question {
+	case welcomeQuestion:
+		return StartAudioStream(request)
+	case browseQuestion:
+		return AcceptBrowse(request)
+	}
+
+	return noQuestionResponse()
+}
+
+// No will answer no to the question the user was last asked. Without a recorded question the
+// user is asked what they would like to do.
+func No(request *Request) *skillserver.EchoResponse {
+
+	question := ""
+	request.SessionAttribute(questionAttribute, &question)
+	switch question {
+	case welcomeQuestion:
+		return skillserver.NewEchoResponse().OutputSpeech("Okay, maybe next time.")
+	case browseQuestion:
+		return DeclineBrowse(request)
+	}
+
+	return noQuestionResponse()
+}
+
+// noQuestionResponse will ask the user what they would like to do when they answered yes or
+// no without being asked a question.
+func noQuestionResponse() (response *skillserver.EchoResponse) {
+
+	response = skillserver.NewEchoResponse()
+	flag := false
+	response.OutputSpeech("Sorry, I'm not sure what you're answering. What would you like to do?").
+		Reprompt("You can ask me to play a channel, or ask me who is live.").
+		EndSession(&flag)
+
+	return
+}
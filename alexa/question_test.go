@@ -0,0 +1,45 @@
+package alexa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+func TestAnswerQuestion(t *testing.T) {
+	cases := []struct {
+		name       string
+		question   func() *skillserver.EchoResponse
+		intentName string
+		speech     string
+		directive  string
+	}{
+		{name: "WelcomeYes", question: func() *skillserver.EchoResponse { return WelcomePrompt(newTestIntentRequest("")) },
+			intentName: "AMAZON.YesIntent", speech: "Starting stream for", directive: "AudioPlayer.Play"},
+		{name: "WelcomeNo", question: func() *skillserver.EchoResponse { return WelcomePrompt(newTestIntentRequest("")) },
+			intentName: "AMAZON.NoIntent", speech: "Okay, maybe next time."},
+		{name: "HelpYes", question: func() *skillserver.EchoResponse { return Help(newTestIntentRequest("")) },
+			intentName: "AMAZON.YesIntent", speech: "Starting stream for", directive: "AudioPlayer.Play"},
+		{name: "BrowseYes", question: func() *skillserver.EchoResponse { return offerBrowseResponse(&browseOffer{}) },
+			intentName: "AMAZON.YesIntent", speech: "Starting stream for Crafter", directive: "AudioPlayer.Play"},
+		{name: "BrowseNo", question: func() *skillserver.EchoResponse { return offerBrowseResponse(&browseOffer{}) },
+			intentName: "AMAZON.NoIntent", speech: "Okay, maybe next time."},
+	}
+
+	router := NewSkillRouter()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			defer env.Close()
+
+			response := router.Route(nextTurn(t, c.question(), c.intentName, nil))
+			if speech := responseSpeech(response); !strings.Contains(speech, c.speech) {
+				t.Fatalf("Expected speech containing %q, got %q", c.speech, speech)
+			}
+			if directive := directiveType(response); directive != c.directive {
+				t.Fatalf("Expected directive %q, got %q", c.directive, directive)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package alexa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/kpango/glg"
+)
+
+// contextKey is the type used for the values this package stores in a request's context.
+type contextKey string
+
+const requestBodyKey contextKey = "requestBody"
+
+// CaptureRequestBody is a negroni middleware that keeps a copy of the raw request body in the
+// request's context. skillserver only decodes the fields common to every request, so the raw
+// body is needed to read the fields specific to AudioPlayer requests. It needs to run before
+// skillserver's middleware which consumes the body.
+func CaptureRequestBody(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		glg.Errorf("Failed to read the request body: %s", err.Error())
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	next(w, r.WithContext(context.WithValue(r.Context(), requestBodyKey, body)))
+}
+
+// RequestBody will return the raw request body saved by CaptureRequestBody, or nil if the
+// body was not captured.
+func RequestBody(r *http.Request) []byte {
+	body, _ := r.Context().Value(requestBodyKey).([]byte)
+	return body
+}
+
+// AudioPlayerState is the state of the device's AudioPlayer that is sent in the context of
+// every request from a device that supports the AudioPlayer interface.
+type AudioPlayerState struct {
+	Token                string `json:"token"`
+	OffsetInMilliseconds int64  `json:"offsetInMilliseconds"`
+	PlayerActivity       string `json:"playerActivity"`
+}
+
+// ParseAudioPlayerState will decode the AudioPlayer state from the raw body of any request.
+// nil is returned if the request doesn't include the AudioPlayer state.
+func ParseAudioPlayerState(body []byte) *AudioPlayerState {
+	request := &struct {
+		Context struct {
+			AudioPlayer *AudioPlayerState `json:"AudioPlayer"`
+		} `json:"context"`
+	}{}
+
+	err := json.Unmarshal(body, request)
+	if err != nil {
+		return nil
+	}
+
+	return request.Context.AudioPlayer
+}
+
+// ParseLocale will decode the user's locale (e.g. en-US) from the raw body of any request.
+// The empty string is returned if the request doesn't include a locale.
+func ParseLocale(body []byte) string {
+	request := &struct {
+		Request struct {
+			Locale string `json:"locale"`
+		} `json:"request"`
+	}{}
+
+	err := json.Unmarshal(body, request)
+	if err != nil {
+		return ""
+	}
+
+	return request.Request.Locale
+}
+
+// AudioPlayerRequest is a request sent by the Alexa AudioPlayer interface. These requests
+// don't have a session, so the user is provided in the request's context instead.
+type AudioPlayerRequest struct {
+	Context struct {
+		System struct {
+			User struct {
+				UserID      string `json:"userId"`
+				AccessToken string `json:"accessToken"`
+			} `json:"user"`
+		} `json:"System"`
+	} `json:"context"`
+	Request AudioPlayerEvent `json:"request"`
+}
+
+// AudioPlayerEvent is the body of an AudioPlayer request.
+type AudioPlayerEvent struct {
+	Type                 string        `json:"type"`
+	RequestID            string        `json:"requestId"`
+	Timestamp            string        `json:"timestamp"`
+	Token                string        `json:"token"`
+	OffsetInMilliseconds int64         `json:"offsetInMilliseconds"`
+	Error                *RequestError `json:"error,omitempty"`
+}
+
+// RequestError describes an error reported by Alexa, e.g. why the AudioPlayer failed to play
+// a stream or why a session ended.
+type RequestError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// ParseAudioPlayerRequest will decode the raw body of an AudioPlayer request.
+func ParseAudioPlayerRequest(body []byte) (*AudioPlayerRequest, error) {
+	request := &AudioPlayerRequest{}
+	err := json.Unmarshal(body, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// Time will return the time the event was sent, or the current time if the timestamp
+// can't be parsed.
+func (event *AudioPlayerEvent) Time() time.Time {
+	timestamp, err := time.Parse(time.RFC3339, event.Timestamp)
+	if err != nil {
+		return time.Now()
+	}
+
+	return timestamp
+}
+
+// Offset will return how far into the stream the event happened.
+func (event *AudioPlayerEvent) Offset() time.Duration {
+	return time.Duration(event.OffsetInMilliseconds) * time.Millisecond
+}
@@ -0,0 +1,179 @@
+package alexa
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+// IntentRequestType is the request type of every request for a skill's intent.
+const IntentRequestType = "IntentRequest"
+
+// Request is a single request from Alexa. skillserver only decodes the fields common to
+// every request, the raw body is kept so handlers can decode the fields specific to their
+// request type.
+type Request struct {
+	*skillserver.EchoRequest
+	Body []byte
+}
+
+// NewRequest will decode the raw body of a request from Alexa.
+func NewRequest(body []byte) (*Request, error) {
+	echoRequest := &skillserver.EchoRequest{}
+	err := json.Unmarshal(body, echoRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Request{EchoRequest: echoRequest, Body: body}, nil
+}
+
+// Handler is a function that responds to a request from Alexa.
+type Handler func(request *Request) *skillserver.EchoResponse
+
+// Middleware wraps a Handler to run code before or after every request is handled.
+type Middleware func(next Handler) Handler
+
+// Router chooses the Handler for each request from Alexa based on the request type, and
+// the intent name for intent requests.
+type Router struct {
+	// Default handles any request that doesn't have a registered handler.
+	Default Handler
+
+	requestHandlers map[string]Handler
+	intentHandlers  map[string]Handler
+	middleware      []Middleware
+}
+
+// NewRouter will create an empty Router that uses UnknownRequest as the default handler.
+func NewRouter() *Router {
+	return &Router{
+		Default:         UnknownRequest,
+		requestHandlers: make(map[string]Handler),
+		intentHandlers:  make(map[string]Handler),
+	}
+}
+
+// HandleRequest will register the handler for the request type. A request type ending in a
+// "." (e.g. "AudioPlayer.") is a prefix that handles every request type in that interface,
+// an exact request type is always preferred over a prefix.
+func (router *Router) HandleRequest(requestType string, handler Handler) {
+	router.requestHandlers[requestType] = handler
+}
+
+// HandleIntent will register the handler for intent requests with the intent name.
+func (router *Router) HandleIntent(intentName string, handler Handler) {
+	router.intentHandlers[intentName] = handler
+}
+
+// Use will add middleware that is run around every handler. Middleware is run in the order
+// it was added, the first middleware added is the outermost.
+func (router *Router) Use(middleware ...Middleware) {
+	router.middleware = append(router.middleware, middleware...)
+}
+
+// handler will return the registered handler for the request, or the default handler.
+func (router *Router) handler(request *Request) Handler {
+	requestType := request.GetRequestType()
+	if requestType == IntentRequestType {
+		if handler, ok := router.intentHandlers[request.Request.Intent.Name]; ok {
+			return handler
+		}
+		return router.Default
+	}
+
+	if handler, ok := router.requestHandlers[requestType]; ok {
+		return handler
+	}
+
+	if index := strings.Index(requestType, "."); index != -1 {
+		if handler, ok := router.requestHandlers[requestType[:index+1]]; ok {
+			return handler
+		}
+	}
+
+	return router.Default
+}
+
+// Route will respond to the request with its handler, wrapped in the router's middleware.
+func (router *Router) Route(request *Request) *skillserver.EchoResponse {
+	handler := router.handler(request)
+	for i := len(router.middleware) - 1; i >= 0; i-- {
+		handler = router.middleware[i](handler)
+	}
+
+	response := handler(request)
+	if response == nil {
+		response = skillserver.NewEchoResponse()
+	}
+
+	return response
+}
+
+// ServeHTTP will route a request that has been verified and decoded by skillserver, so the
+// router can be used as the Handler of a skillserver.EchoApplication. CaptureRequestBody
+// needs to run before skillserver for the raw body to be available.
+func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	request := &Request{EchoRequest: skillserver.GetEchoRequest(r), Body: RequestBody(r)}
+
+	json, err := router.Route(request).String()
+	if err != nil {
+		glg.Errorf("Failed to encode the response: %s", err.Error())
+		http.Error(w, "Internal Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Write(json)
+}
+
+// LogRequests is middleware that logs each request and how long it took to handle.
+func LogRequests(next Handler) Handler {
+	return func(request *Request) *skillserver.EchoResponse {
+		start := time.Now()
+		glg.Infof("RequestType: %s, IntentName: %s", request.GetRequestType(), request.GetIntentName())
+
+		response := next(request)
+		glg.Infof("%s execution time: %v", request.GetIntentName(), time.Since(start))
+
+		return response
+	}
+}
+
+// RecoverPanics is middleware that responds with an apology instead of dropping the
+// connection if a handler panics.
+func RecoverPanics(next Handler) Handler {
+	return func(request *Request) (response *skillserver.EchoResponse) {
+		defer func() {
+			if r := recover(); r != nil {
+				glg.Errorf("Recovered from panic handling %s: %v", request.GetIntentName(), r)
+				response = skillserver.NewEchoResponse()
+				if request.GetRequestType() == IntentRequestType || request.GetRequestType() == "LaunchRequest" {
+					response.OutputSpeech("Sorry, something went wrong, please try again later.")
+				}
+			}
+		}()
+
+		return next(request)
+	}
+}
+
+// UnknownRequest is the default handler for requests without a registered handler. Intent
+// requests get an apology, any other request type gets an empty response since most of
+// them can't include speech.
+func UnknownRequest(request *Request) (response *skillserver.EchoResponse) {
+
+	response = skillserver.NewEchoResponse()
+	if request.GetRequestType() == IntentRequestType {
+		response.OutputSpeech("Sorry Guardian, I did not understand your request.")
+		return
+	}
+
+	glg.Warnf("Unsupported request type: %s", request.GetRequestType())
+
+	return
+}
@@ -0,0 +1,193 @@
+package alexa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+// speechHandler will create a handler that responds with the provided speech.
+func speechHandler(speech string) Handler {
+	return func(request *Request) *skillserver.EchoResponse {
+		return skillserver.NewEchoResponse().OutputSpeech(speech)
+	}
+}
+
+func routerTestRequest(requestType, intentName string) *Request {
+	request := newTestIntentRequest(intentName)
+	request.Request.Type = requestType
+
+	return request
+}
+
+func responseSpeech(response *skillserver.EchoResponse) string {
+	if response.Response.OutputSpeech == nil {
+		return ""
+	}
+
+	if response.Response.OutputSpeech.Type == "SSML" {
+		return response.Response.OutputSpeech.SSML
+	}
+
+	return response.Response.OutputSpeech.Text
+}
+
+func TestRouterChoosesHandler(t *testing.T) {
+	router := NewRouter()
+	router.Default = speechHandler("default")
+	router.HandleRequest("LaunchRequest", speechHandler("launch"))
+	router.HandleRequest("AudioPlayer.", speechHandler("audio player"))
+	router.HandleRequest("AudioPlayer.PlaybackFailed", speechHandler("failed"))
+	router.HandleIntent("AMAZON.HelpIntent", speechHandler("help"))
+
+	cases := []struct {
+		requestType, intentName, expected string
+	}{
+		{"LaunchRequest", "", "launch"},
+		{IntentRequestType, "AMAZON.HelpIntent", "help"},
+		{IntentRequestType, "UnknownIntent", "default"},
+		{"AudioPlayer.PlaybackStarted", "", "audio player"},
+		{"AudioPlayer.PlaybackFailed", "", "failed"},
+		{"PlaybackController.NextCommandIssued", "", "default"},
+		{"AudioPlayerish", "", "default"},
+	}
+
+	for _, c := range cases {
+		speech := responseSpeech(router.Route(routerTestRequest(c.requestType, c.intentName)))
+		if speech != c.expected {
+			t.Errorf("%s %s: expected %q, got %q", c.requestType, c.intentName, c.expected, speech)
+		}
+	}
+}
+
+func TestRouterMiddlewareOrder(t *testing.T) {
+	calls := make([]string, 0)
+	middleware := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(request *Request) *skillserver.EchoResponse {
+				calls = append(calls, name)
+				return next(request)
+			}
+		}
+	}
+
+	router := NewRouter()
+	router.Use(middleware("first"), middleware("second"))
+	router.HandleIntent("Test", func(request *Request) *skillserver.EchoResponse {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	response := router.Route(routerTestRequest(IntentRequestType, "Test"))
+	if response == nil {
+		t.Fatalf("A nil response from a handler should be replaced with an empty response")
+	}
+	if strings.Join(calls, ",") != "first,second,handler" {
+		t.Fatalf("Middleware was run in the wrong order: %v", calls)
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	router := NewRouter()
+	router.Use(RecoverPanics)
+	router.HandleIntent("Panic", func(request *Request) *skillserver.EchoResponse {
+		panic("handler failed")
+	})
+
+	response := router.Route(routerTestRequest(IntentRequestType, "Panic"))
+	if responseSpeech(response) == "" {
+		t.Fatalf("Expected an apology after a panic")
+	}
+}
+
+// TestSkillRouter covers every request type and intent handled by the skill. Every new
+// handler registered in NewSkillRouter should add a case here.
+func TestSkillRouter(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+	router := NewSkillRouter()
+
+	cases := []struct {
+		name        string
+		requestType string
+		intentName  string
+		body        []byte
+		speech      string
+		directive   string
+		endSession  bool
+	}{
+		{name: "Launch", requestType: "LaunchRequest", speech: "Welcome", endSession: true},
+		{name: "Play", requestType: IntentRequestType, intentName: "StartAudioStream",
+			speech: "Starting stream for Streamer", directive: "AudioPlayer.Play"},
+		{name: "PlayVideo", requestType: IntentRequestType, intentName: "StartVideoStream",
+			speech: "Starting stream for Streamer", directive: "AudioPlayer.Play"},
+		{name: "PlayChannel", requestType: IntentRequestType, intentName: "PlayChannel",
+			speech: "Which channel", directive: "Dialog.ElicitSlot", endSession: true},
+		{name: "PlayCategory", requestType: IntentRequestType, intentName: "PlayCategory",
+			speech: "Which game", directive: "Dialog.ElicitSlot", endSession: true},
+		{name: "ChooseStream", requestType: IntentRequestType, intentName: "ChooseStream",
+			speech: "Say a number or a name", endSession: true},
+		{name: "SelectStreamWithoutChoices", requestType: IntentRequestType, intentName: "SelectStream",
+			speech: "did not understand"},
+		{name: "MoreWithoutChoices", requestType: IntentRequestType, intentName: "AMAZON.MoreIntent",
+			speech: "did not understand"},
+		{name: "YesWithoutQuestion", requestType: IntentRequestType, intentName: "AMAZON.YesIntent",
+			speech: "What would you like to do?", endSession: true},
+		{name: "NoWithoutQuestion", requestType: IntentRequestType, intentName: "AMAZON.NoIntent",
+			speech: "What would you like to do?", endSession: true},
+		{name: "WhosLive", requestType: IntentRequestType, intentName: "WhosLive",
+			speech: "2 of your followed channels are live."},
+		{name: "WhatsPlaying", requestType: IntentRequestType, intentName: "WhatsPlaying",
+			speech: "You're listening to"},
+		{name: "Next", requestType: IntentRequestType, intentName: "AMAZON.NextIntent",
+			speech: "Starting stream for Other", directive: "AudioPlayer.Play"},
+		{name: "Previous", requestType: IntentRequestType, intentName: "AMAZON.PreviousIntent",
+			speech: "Starting stream for Streamer", directive: "AudioPlayer.Play"},
+		{name: "Resume", requestType: IntentRequestType, intentName: "AMAZON.ResumeIntent",
+			speech: "Starting stream for Streamer", directive: "AudioPlayer.Play"},
+		{name: "Pause", requestType: IntentRequestType, intentName: "AMAZON.PauseIntent",
+			speech: "Twitch ya later", directive: "AudioPlayer.Stop"},
+		{name: "Help", requestType: IntentRequestType, intentName: "AMAZON.HelpIntent",
+			speech: "Twitch Box plays", endSession: true},
+		{name: "Stop", requestType: IntentRequestType, intentName: "AMAZON.StopIntent"},
+		{name: "Cancel", requestType: IntentRequestType, intentName: "AMAZON.CancelIntent"},
+		{name: "UnknownIntent", requestType: IntentRequestType, intentName: "UnknownIntent",
+			speech: "did not understand"},
+		{name: "SessionEnded", requestType: "SessionEndedRequest",
+			body: []byte(`{"request": {"reason": "ERROR", "error": {"type": "INVALID_RESPONSE", "message": "bad"}}}`)},
+		{name: "ExceptionEncountered", requestType: "System.ExceptionEncountered",
+			body: []byte(`{"request": {"error": {"type": "INVALID_RESPONSE", "message": "bad"}, "cause": {"requestId": "id"}}}`)},
+		{name: "SkillEnabled", requestType: "AlexaSkillEvent.SkillEnabled",
+			body: []byte(`{"context": {"System": {"user": {"userId": "amzn1.ask.account.test"}}}}`)},
+		{name: "AudioPlayer", requestType: PlaybackStarted,
+			body: audioPlayerRequestBody(PlaybackStarted, "token", "", 0, "")},
+		{name: "PlaybackController", requestType: PauseCommandIssued,
+			body: playbackControllerRequestBody(PauseCommandIssued, "token", ""), directive: "AudioPlayer.Stop"},
+		{name: "UnknownRequest", requestType: "Unknown.Request"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			request := routerTestRequest(c.requestType, c.intentName)
+			if c.body != nil {
+				request.Body = c.body
+			}
+			response := router.Route(request)
+
+			speech := responseSpeech(response)
+			if (c.speech == "" && speech != "") || !strings.Contains(speech, c.speech) {
+				t.Fatalf("Expected speech containing %q, got %q", c.speech, speech)
+			}
+
+			if directive := directiveType(response); directive != c.directive {
+				t.Fatalf("Expected directive %q, got %q", c.directive, directiveType(response))
+			}
+
+			keepOpen := response.Response.ShouldEndSession != nil && !*response.Response.ShouldEndSession
+			if keepOpen != c.endSession {
+				t.Fatalf("Expected the session to stay open=%t", c.endSession)
+			}
+		})
+	}
+}
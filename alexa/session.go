@@ -0,0 +1,43 @@
+package alexa
+
+import (
+	"encoding/json"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+// SessionAttribute will decode the session attribute with the name into value. Alexa sends
+// back the session attributes from the previous response in the session with each request.
+// false is returned if the request's session doesn't have the attribute or it can't be
+// decoded into value.
+func (request *Request) SessionAttribute(name string, value interface{}) bool {
+	attribute, ok := request.Session.Attributes[name]
+	if !ok || attribute == nil {
+		return false
+	}
+
+	// The attributes are decoded as generic JSON values, so encode the attribute again to
+	// decode it into the value's type.
+	data, err := json.Marshal(attribute)
+	if err == nil {
+		err = json.Unmarshal(data, value)
+	}
+	if err != nil {
+		glg.Warnf("Ignoring invalid session attribute(%s): %s", name, err.Error())
+		return false
+	}
+
+	return true
+}
+
+// SetSessionAttribute will save the value in the response's session attributes so it is sent
+// back with the next request in the session. Attributes are only kept for the next request,
+// every response needs to set the attributes it wants to keep.
+func SetSessionAttribute(response *skillserver.EchoResponse, name string, value interface{}) {
+	if response.SessionAttributes == nil {
+		response.SessionAttributes = make(map[string]interface{})
+	}
+
+	response.SessionAttributes[name] = value
+}
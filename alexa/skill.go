@@ -0,0 +1,134 @@
+package alexa
+
+import (
+	"encoding/json"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+)
+
+// NewSkillRouter will create the router with every request and intent handled by the skill.
+func NewSkillRouter() *Router {
+	router := NewRouter()
+	router.Use(LogRequests, RecoverPanics)
+
+	router.HandleRequest("LaunchRequest", WelcomePrompt)
+	router.HandleRequest("SessionEndedRequest", SessionEnded)
+	router.HandleRequest("System.ExceptionEncountered", ExceptionEncountered)
+	router.HandleRequest("AlexaSkillEvent.", SkillEvent)
+	router.HandleRequest("AudioPlayer.", AudioPlayerHandler)
+	router.HandleRequest("PlaybackController.", PlaybackControllerHandler)
+
+	router.HandleIntent("StartAudioStream", StartAudioStream)
+	router.HandleIntent("StartVideoStream", StartVideoStream)
+	router.HandleIntent("PlayChannel", PlayChannel)
+	router.HandleIntent("PlayCategory", PlayCategory)
+	router.HandleIntent("ChooseStream", ChooseStream)
+	router.HandleIntent("SelectStream", SelectStream)
+	router.HandleIntent("AMAZON.MoreIntent", MoreStreams)
+	router.HandleIntent("AMAZON.YesIntent", Yes)
+	router.HandleIntent("AMAZON.NoIntent", No)
+	router.HandleIntent("WhosLive", WhosLive)
+	router.HandleIntent("WhatsPlaying", WhatsPlaying)
+	router.HandleIntent("AMAZON.NextIntent", StartAudioStream)
+	router.HandleIntent("AMAZON.PreviousIntent", StartAudioStream)
+	router.HandleIntent("AMAZON.ResumeIntent", ResumeAudioStream)
+	router.HandleIntent("AMAZON.PauseIntent", PauseAudioStream)
+	router.HandleIntent("AMAZON.HelpIntent", Help)
+	router.HandleIntent("AMAZON.StopIntent", EmptyResponse)
+	router.HandleIntent("AMAZON.CancelIntent", EmptyResponse)
+
+	return router
+}
+
+// requestWithError is the part of a request body that describes an error reported by Alexa.
+// skillserver doesn't decode it for any request type.
+type requestWithError struct {
+	Request struct {
+		Error *RequestError `json:"error"`
+		Cause struct {
+			RequestID string `json:"requestId"`
+		} `json:"cause"`
+	} `json:"request"`
+}
+
+// parseRequestError will decode the error and the ID of the request that caused it from the
+// raw request body.
+func parseRequestError(body []byte) (*RequestError, string) {
+	request := &requestWithError{}
+	err := json.Unmarshal(body, request)
+	if err != nil || request.Request.Error == nil {
+		return &RequestError{}, request.Request.Cause.RequestID
+	}
+
+	return request.Request.Error, request.Request.Cause.RequestID
+}
+
+// Help will explain what the user can ask the skill to do.
+func Help(request *Request) (response *skillserver.EchoResponse) {
+
+	response = skillserver.NewEchoResponse()
+	flag := false
+	response.OutputSpeech("Twitch Box plays the live streams from the channels you follow on Twitch. " +
+		"You can ask me who is live or what's playing, ask me to play a channel or a game by name, or say " +
+		"play, next, previous, pause or resume. What would you like to do?").
+		Reprompt("Would you like to start playing one of your followed streams?").
+		EndSession(&flag)
+	askQuestion(response, welcomeQuestion)
+
+	return
+}
+
+// PauseAudioStream will stop the stream playing on the user's device.
+func PauseAudioStream(request *Request) *skillserver.EchoResponse {
+	return StopAudioDirective()
+}
+
+// EmptyResponse will respond without any speech or directives.
+func EmptyResponse(request *Request) *skillserver.EchoResponse {
+	return skillserver.NewEchoResponse()
+}
+
+// SessionEnded is responsible for cleaning up an open session since the user has quit the
+// session, didn't respond, or an error occurred. The response can't include any speech.
+func SessionEnded(request *Request) *skillserver.EchoResponse {
+	reason := request.Request.Reason
+	if reason == "ERROR" {
+		requestErr, _ := parseRequestError(request.Body)
+		glg.Errorf("Session %s ended with an error: %s %s", request.GetSessionID(), requestErr.Type,
+			requestErr.Message)
+	} else {
+		glg.Infof("Session %s ended: %s", request.GetSessionID(), reason)
+	}
+
+	return skillserver.NewEchoResponse()
+}
+
+// ExceptionEncountered will log the error Alexa encountered with a previous response from the
+// skill. The response to this request can't include anything.
+func ExceptionEncountered(request *Request) *skillserver.EchoResponse {
+	requestErr, causeRequestID := parseRequestError(request.Body)
+	glg.Errorf("Alexa encountered an exception handling the response to request %s: %s %s",
+		causeRequestID, requestErr.Type, requestErr.Message)
+
+	return skillserver.NewEchoResponse()
+}
+
+// SkillEvent will log the skill events (enabled, disabled, account linked and permission
+// changes) Alexa sends when the skill is subscribed to them.
+func SkillEvent(request *Request) *skillserver.EchoResponse {
+	event := &struct {
+		Context struct {
+			System struct {
+				User struct {
+					UserID string `json:"userId"`
+				} `json:"user"`
+			} `json:"System"`
+		} `json:"context"`
+	}{}
+	json.Unmarshal(request.Body, event)
+
+	glg.Infof("%s: user=%s", request.GetRequestType(), event.Context.System.User.UserID)
+
+	return skillserver.NewEchoResponse()
+}
@@ -0,0 +1,115 @@
+package alexa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// streamTokenVersion is the prefix of every stream token so the format can be changed later
+// without misreading older tokens.
+const streamTokenVersion = "v1"
+
+// ErrInvalidStreamToken is returned when a stream token was not created by this server,
+// was created with a different key, or is not in the expected format.
+var ErrInvalidStreamToken = errors.New("invalid stream token")
+
+// StreamToken is the information encoded in the token sent with every AudioPlayer.Play
+// directive. Alexa sends the token back with each AudioPlayer request so the request can
+// be tied back to the stream that was playing.
+type StreamToken struct {
+	// ChannelID is the user ID of the streamer.
+	ChannelID string `json:"c"`
+	// Variant is the name of the stream variant that was played.
+	Variant string `json:"v"`
+	// VODID is the ID of the past broadcast that was played, empty for live streams.
+	VODID string `json:"d,omitempty"`
+	// StartedAt is when the stream was started.
+	StartedAt int64 `json:"t"`
+	// Nonce makes every token unique, even for the same channel and variant.
+	Nonce string `json:"n"`
+}
+
+// NewStreamToken will create a new token for the channel and variant started now.
+func NewStreamToken(channelID, variant string) *StreamToken {
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+
+	return &StreamToken{
+		ChannelID: channelID,
+		Variant:   variant,
+		StartedAt: time.Now().Unix(),
+		Nonce:     hex.EncodeToString(nonce),
+	}
+}
+
+// Started will return the time the stream was started.
+func (token *StreamToken) Started() time.Time {
+	return time.Unix(token.StartedAt, 0)
+}
+
+// StreamTokenSigner signs stream tokens with an HMAC so tokens sent back by Alexa can't be
+// forged or altered to reference a different channel or variant. Tokens aren't tied to the
+// user they were created for, so a token only identifies what was played, not who played it.
+type StreamTokenSigner struct {
+	key []byte
+}
+
+// NewStreamTokenSigner will create a signer that uses the provided secret key. If the key is
+// empty a random key is generated, tokens signed with it can't be verified after a restart.
+func NewStreamTokenSigner(key []byte) *StreamTokenSigner {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+
+	return &StreamTokenSigner{key: key}
+}
+
+// Sign will encode and sign the token. The result is in the format
+// "v1.<base64 payload>.<base64 signature>".
+func (signer *StreamTokenSigner) Sign(token *StreamToken) string {
+	payloadJSON, _ := json.Marshal(token)
+	payload := streamTokenVersion + "." + base64.RawURLEncoding.EncodeToString(payloadJSON)
+
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signer.mac(payload))
+}
+
+// Parse will verify the signature of the token value and decode it.
+func (signer *StreamTokenSigner) Parse(value string) (*StreamToken, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 || parts[0] != streamTokenVersion {
+		return nil, ErrInvalidStreamToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, signer.mac(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidStreamToken
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidStreamToken
+	}
+
+	token := &StreamToken{}
+	err = json.Unmarshal(payloadJSON, token)
+	if err != nil || token.ChannelID == "" {
+		return nil, ErrInvalidStreamToken
+	}
+
+	return token, nil
+}
+
+func (signer *StreamTokenSigner) mac(payload string) []byte {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
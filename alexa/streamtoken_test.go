@@ -0,0 +1,45 @@
+package alexa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamTokenRoundTrip(t *testing.T) {
+	signer := NewStreamTokenSigner([]byte("secret"))
+	token := NewStreamToken("2000", "audio_only")
+
+	parsed, err := signer.Parse(signer.Sign(token))
+	if err != nil {
+		t.Fatalf("Failed to parse a signed token: %s", err.Error())
+	}
+	if *parsed != *token {
+		t.Fatalf("Expected %+v, got %+v", token, parsed)
+	}
+
+	if signer.Sign(token) == signer.Sign(NewStreamToken("2000", "audio_only")) {
+		t.Fatalf("Tokens for the same channel and variant should be unique")
+	}
+}
+
+func TestStreamTokenRejectsInvalidTokens(t *testing.T) {
+	signer := NewStreamTokenSigner([]byte("secret"))
+	value := signer.Sign(NewStreamToken("2000", "audio_only"))
+	parts := strings.Split(value, ".")
+	forged := signer.Sign(NewStreamToken("3000", "audio_only"))
+
+	cases := map[string]string{
+		"Empty":         "",
+		"Legacy":        "12345",
+		"OtherKey":      NewStreamTokenSigner([]byte("other")).Sign(NewStreamToken("2000", "audio_only")),
+		"SwappedClaims": parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2],
+		"BadSignature":  parts[0] + "." + parts[1] + ".bad",
+		"OtherVersion":  "v2." + parts[1] + "." + parts[2],
+	}
+
+	for name, value := range cases {
+		if _, err := signer.Parse(value); err != ErrInvalidStreamToken {
+			t.Errorf("%s: expected ErrInvalidStreamToken, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package alexa
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kpango/glg"
+	"github.com/rking788/go-alexa/skillserver"
+	"github.com/rking788/twitch-box/twitch"
+)
+
+// WhosLiveCount is the number of live channels named when the user asks who is live, the
+// card lists all of them.
+const WhosLiveCount = 3
+
+// ssmlEscaper escapes the characters in names and games that can't be used in SSML.
+var ssmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+// WhosLive will tell the user how many of their followed channels are live and name the
+// most watched ones with the game they are playing and how long they have been live. A card
+// lists every live channel. Playback is never started.
+func WhosLive(request *Request) (response *skillserver.EchoResponse) {
+
+	accessToken := request.Session.User.AccessToken
+	if accessToken == "" {
+		return linkAccountResponse()
+	}
+
+	tokenInfo, err := twitchClient.RequireToken(accessToken)
+	if err != nil {
+		glg.Errorf("Error validating the user's access token: %s", err.Error())
+		return ErrorResponse(err)
+	}
+
+	liveStreams, err := twitchClient.GetLiveFollows(accessToken, tokenInfo.User())
+	if twitch.IsPartial(err) {
+		glg.Warnf("Only some of the followed channels could be checked: %s", err.Error())
+	} else if err != nil {
+		glg.Errorf("Error loading live streams: %s", err.Error())
+		return ErrorResponse(err)
+	}
+
+	response = skillserver.NewEchoResponse()
+	streams := liveStreams.Data
+	if len(streams) == 0 {
+		response.OutputSpeech("None of your followed channels are live right now.")
+		return
+	}
+
+	builder := skillserver.NewSSMLTextBuilder()
+	if len(streams) == 1 {
+		builder.AppendSentence("One of your followed channels is live.")
+	} else {
+		builder.AppendSentence(fmt.Sprintf("%d of your followed channels are live.", len(streams)))
+	}
+
+	for index, stream := range streams {
+		if index == WhosLiveCount {
+			builder.AppendSentence(fmt.Sprintf("And %d more.", len(streams)-WhosLiveCount))
+			break
+		}
+		builder.AppendSentence(ssmlEscaper.Replace(liveSummary(stream)))
+	}
+
+	lines := make([]string, 0, len(streams))
+	for _, stream := range streams {
+		lines = append(lines, liveCardLine(stream))
+	}
+
+	response.OutputSpeechSSML(builder.Build()).
+		SimpleCard("Live Channels", strings.Join(lines, "\n"))
+
+	return
+}
+
+// liveSummary will describe what the stream is playing and how long it has been live.
+func liveSummary(stream *twitch.Stream) string {
+	summary := stream.UserName
+	if stream.GameName != "" {
+		summary += " is playing " + stream.GameName
+		if uptime := stream.Uptime(); uptime > 0 {
+			summary += " and has been live for " + spokenDuration(uptime)
+		}
+	} else if uptime := stream.Uptime(); uptime > 0 {
+		summary += " has been live for " + spokenDuration(uptime)
+	} else {
+		summary += " is live"
+	}
+
+	return summary + "."
+}
+
+// liveCardLine will describe the stream in a single line of a card.
+func liveCardLine(stream *twitch.Stream) string {
+	line := stream.UserName
+	if stream.GameName != "" {
+		line += " - " + stream.GameName
+	}
+	if uptime := stream.Uptime(); uptime > 0 {
+		line += " (" + shortDuration(uptime) + ")"
+	}
+
+	return line
+}
+
+// spokenDuration will describe the duration in hours and minutes the way it would be said,
+// e.g. "2 hours and 5 minutes".
+func spokenDuration(duration time.Duration) string {
+	hours := int(duration.Hours())
+	minutes := int(duration.Minutes()) % 60
+
+	switch {
+	case hours == 0 && minutes == 0:
+		return "less than a minute"
+	case hours == 0:
+		return plural(minutes, "minute")
+	case minutes == 0:
+		return plural(hours, "hour")
+	}
+
+	return plural(hours, "hour") + " and " + plural(minutes, "minute")
+}
+
+// shortDuration will format the duration in hours and minutes for a card, e.g. "2h 5m".
+func shortDuration(duration time.Duration) string {
+	return fmt.Sprintf("%dh %dm", int(duration.Hours()), int(duration.Minutes())%60)
+}
+
+// plural will format the count with the singular or plural form of the unit.
+func plural(count int, unit string) string {
+	if count == 1 {
+		return "1 " + unit
+	}
+
+	return fmt.Sprintf("%d %ss", count, unit)
+}
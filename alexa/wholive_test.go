@@ -0,0 +1,68 @@
+package alexa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rking788/twitch-box/twitch"
+)
+
+func TestWhosLive(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Close()
+
+	response := WhosLive(newTestIntentRequest("WhosLive"))
+	expected := "<speak><s>2 of your followed channels are live.</s>" +
+		"<s>Streamer is playing Minecraft and has been live for 2 hours and 5 minutes.</s>" +
+		"<s>Other is live.</s></speak>"
+	if speech := responseSpeech(response); speech != expected {
+		t.Fatalf("Expected speech %q, got %q", expected, speech)
+	}
+	if len(response.Response.Directives) != 0 {
+		t.Fatalf("Asking who is live should never start playback: %+v", response.Response.Directives)
+	}
+	if card := response.Response.Card; card == nil || card.Content != "Streamer - Minecraft (2h 5m)\nOther" {
+		t.Fatalf("Expected a card listing every live channel: %+v", card)
+	}
+	if queue, _ := env.queues.Queue("1000"); queue.Current() != "" {
+		t.Fatalf("Asking who is live should not change the playback queue")
+	}
+}
+
+func TestWhosLiveWithoutAccount(t *testing.T) {
+	request := newTestIntentRequest("WhosLive")
+	request.Session.User.AccessToken = ""
+
+	response := WhosLive(request)
+	if response.Response.Card == nil || response.Response.Card.Type != "LinkAccount" {
+		t.Fatalf("Expected a link account card: %+v", response.Response.Card)
+	}
+}
+
+func TestLiveSummary(t *testing.T) {
+	cases := []struct {
+		stream   *twitch.Stream
+		expected string
+	}{
+		{&twitch.Stream{UserName: "A", GameName: "Chess", StartedAt: time.Now().Add(-61 * time.Minute)},
+			"A is playing Chess and has been live for 1 hour and 1 minute."},
+		{&twitch.Stream{UserName: "B", StartedAt: time.Now().Add(-3 * time.Hour)},
+			"B has been live for 3 hours."},
+		{&twitch.Stream{UserName: "C", GameName: "Just Chatting"}, "C is playing Just Chatting."},
+		{&twitch.Stream{UserName: "D", StartedAt: time.Now().Add(-10 * time.Second)},
+			"D has been live for less than a minute."},
+	}
+
+	for _, c := range cases {
+		if summary := liveSummary(c.stream); summary != c.expected {
+			t.Errorf("Expected %q, got %q", c.expected, summary)
+		}
+	}
+}
+
+func TestWhosLiveEscapesSSML(t *testing.T) {
+	escaped := ssmlEscaper.Replace(liveSummary(&twitch.Stream{UserName: "Tom & Jerry", GameName: "<Game>"}))
+	if escaped != "Tom &amp; Jerry is playing &lt;Game&gt;." {
+		t.Fatalf("Expected the names to be escaped for SSML, got %q", escaped)
+	}
+}
@@ -3,28 +3,16 @@ package main
 import (
 	"net/http"
 	"os"
-	"time"
 
+	"github.com/codegangsta/negroni"
+	"github.com/gorilla/mux"
 	"github.com/kpango/glg"
 	"github.com/rking788/go-alexa/skillserver"
 	"github.com/rking788/twitch-box/alexa"
+	"github.com/rking788/twitch-box/store"
 	"github.com/rking788/twitch-box/twitch"
 )
 
-// AlexaHandler is the type of function that should be used to respond to a specific intent.
-type AlexaHandler func(*skillserver.EchoRequest) *skillserver.EchoResponse
-
-// AlexaHandlers are the handler functions mapped by the intent name that they should handle.
-var (
-	AlexaHandlers = map[string]AlexaHandler{
-		"StartAudioStream":      alexa.StartAudioStream,
-		"StartVideoStream":      alexa.StartVideoStream,
-		"AMAZON.NextIntent":     alexa.StartAudioStream,
-		"AMAZON.PreviousIntent": alexa.StartAudioStream,
-		"AMAZON.ResumeIntent":   alexa.StartAudioStream,
-	}
-)
-
 // Applications is a definition of the Alexa applications running on this server.
 var applications map[string]interface{}
 
@@ -42,10 +30,8 @@ const (
 func InitEnv() {
 	applications = map[string]interface{}{
 		"/echo/twitch-box": skillserver.EchoApplication{ // Route
-			AppID:          os.Getenv("ALEXA_APP_ID"), // Echo App ID from Amazon Dashboard
-			OnIntent:       EchoIntentHandler,
-			OnLaunch:       EchoIntentHandler,
-			OnSessionEnded: EchoSessionEndedHandler,
+			AppID:   os.Getenv("ALEXA_APP_ID"), // Echo App ID from Amazon Dashboard
+			Handler: alexa.NewSkillRouter().ServeHTTP,
 		},
 		"/health": skillserver.StdApplication{
 			Methods: "GET",
@@ -83,7 +69,54 @@ func main() {
 	//	config = loadConfig(configPath)
 
 	//	glg.Infof("Loaded config : %+v\n", config)
-	twitch.InitEnv(os.Getenv("REDIS_URL"))
+	twitchClient := twitch.NewClient(os.Getenv("TWITCH_API_CLIENT_ID"))
+	// Endpoints that need an app access token reject requests without one, which would look
+	// like the user's account link had expired, so don't start without the secret.
+	secret := os.Getenv("TWITCH_API_CLIENT_SECRET")
+	if secret == "" {
+		glg.Fatal("TWITCH_API_CLIENT_SECRET is not set, it is needed to request app access tokens")
+	}
+	twitchClient.AppTokens = twitch.NewAppTokenSource(secret)
+	twitchClient.LegacyFollowsFallback = os.Getenv("TWITCH_BOX_LEGACY_FOLLOWS") == "true"
+	var history twitch.HistoryStore = twitch.NewMemoryHistoryStore()
+	var queues twitch.QueueStore = twitch.NewMemoryQueueStore()
+	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
+		history = twitch.NewRedisHistoryStore(redisURL)
+		queues = twitch.NewRedisQueueStore(redisURL)
+	} else {
+		glg.Warn("REDIS_URL is not set, recent streams will only be cached in memory")
+	}
+	var recorder alexa.ListeningRecorder
+	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
+		db, err := store.Open(databaseURL)
+		if err != nil {
+			glg.Fatalf("Failed to connect to the database: %s", err.Error())
+		}
+		err = db.Migrate()
+		if err == nil {
+			err = db.CheckMigrations()
+		}
+		if err != nil {
+			glg.Fatalf("Database migration check failed: %s", err.Error())
+		}
+		history = twitch.NewCachedHistoryStore(history, db)
+		recorder = db
+	} else {
+		glg.Warn("DATABASE_URL is not set, recent streams will not be persisted")
+	}
+	tokenSecret := os.Getenv("TWITCH_BOX_TOKEN_SECRET")
+	if tokenSecret == "" {
+		glg.Warn("TWITCH_BOX_TOKEN_SECRET is not set, stream tokens will not be valid after a restart")
+	}
+	alexa.InitEnv(alexa.Env{
+		Client:           twitchClient,
+		History:          history,
+		Queues:           queues,
+		Recorder:         recorder,
+		StreamTokens:     alexa.NewStreamTokenSigner([]byte(tokenSecret)),
+		AskWhichStream:   os.Getenv("TWITCH_BOX_ASK_WHICH_STREAM") == "true",
+		BrowseTopStreams: os.Getenv("TWITCH_BOX_BROWSE_TOP_STREAMS") == "true",
+	})
 	InitEnv()
 
 	//	defer CloseLogger()
@@ -102,70 +135,24 @@ func main() {
 	// Heroku makes us read a random port from the environment and our app is a
 	// subdomain of theirs so we get SSL for free
 	port := os.Getenv("PORT")
-	skillserver.Run(applications, port)
+	run(applications, port)
 	//}
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("Up"))
-}
-
-// Alexa skill related functions
-
-// EchoSessionEndedHandler is responsible for cleaning up an open session since the
-// user has quit the session.
-func EchoSessionEndedHandler(echoRequest *skillserver.EchoRequest,
-	echoResponse *skillserver.EchoResponse) {
-
-	*echoResponse = *skillserver.NewEchoResponse()
+// run is the same as skillserver.Run except the raw request body is captured before
+// skillserver's middleware consumes it.
+func run(apps map[string]interface{}, port string) {
+	router := mux.NewRouter()
+	skillserver.Init(apps, router)
 
-	//alexa.ClearSession(echoRequest.GetSessionID())
+	n := negroni.Classic()
+	n.Use(negroni.HandlerFunc(alexa.CaptureRequestBody))
+	n.UseHandler(router)
+	n.Run(":" + port)
 }
 
-// EchoIntentHandler is a handler method that is responsible for receiving the
-// call from a Alexa command and returning the correct speech or cards.
-func EchoIntentHandler(echoRequest *skillserver.EchoRequest, echoResponse *skillserver.EchoResponse) {
-	// Time the intent handler to determine if it is taking longer than normal
-	startTime := time.Now()
-	defer func(start time.Time) {
-		glg.Infof("IntentHandler execution time: %v", time.Since(start))
-	}(startTime)
-
-	var response *skillserver.EchoResponse
-
-	intentName := echoRequest.GetIntentName()
-
-	glg.Infof("RequestType: %s, IntentName: %s", echoRequest.GetRequestType(), intentName)
-
-	// During this time, users can invoke the following built-in playback control intents without using your skill’s invocation name:
-
-	// AMAZON.CancelIntent
-	// AMAZON.LoopOffIntent
-	// AMAZON.LoopOnIntent
-	// AMAZON.RepeatIntent
-	// AMAZON.ResumeIntent
-	// AMAZON.ShuffleOffIntent
-	// AMAZON.ShuffleOnIntent
-	// AMAZON.StartOverIntent
-
-	handler, ok := AlexaHandlers[intentName]
-	if echoRequest.GetRequestType() == "LaunchRequest" {
-		response = alexa.WelcomePrompt(echoRequest)
-	} else if intentName == "AMAZON.StopIntent" {
-		response = skillserver.NewEchoResponse()
-	} else if intentName == "AMAZON.CancelIntent" {
-		response = skillserver.NewEchoResponse()
-	} else if intentName == "AMAZON.PauseIntent" {
-		// Send stop directive
-		response = alexa.StopAudioDirective()
-	} else if ok {
-		response = handler(echoRequest)
-	} else {
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, I did not understand your request.")
-	}
-
-	*echoResponse = *response
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("Up"))
 }
 
 // func dumpRequest(ctx *gin.Context) {
@@ -0,0 +1,202 @@
+// Package matcher finds the names that best match a name returned by speech recognition.
+// Names like "xQcOW", "summit1g" or "lirik_" rarely come back spelled correctly, so the
+// spoken name is normalized into the ways it could be written and each candidate is scored
+// with phonetic encodings and edit distance.
+package matcher
+
+import (
+	"sort"
+	"strings"
+)
+
+// The defaults used by a Matcher created with New.
+const (
+	DefaultMinConfidence     = 0.7
+	DefaultCertainConfidence = 0.9
+	DefaultAmbiguityMargin   = 0.1
+)
+
+// Candidate is something that can be matched by name, e.g. a channel with its login and
+// display name.
+type Candidate struct {
+	// ID identifies the candidate to the caller.
+	ID string
+	// Names are all of the names the candidate could be spoken as.
+	Names []string
+}
+
+// Match is a candidate that matched a spoken name.
+type Match struct {
+	Candidate Candidate
+	// Name is the candidate's name that best matched the spoken name.
+	Name string
+	// Confidence is how well the name matched, from 0 (not at all) to 1 (exactly).
+	Confidence float64
+}
+
+// Matcher scores candidates against spoken names.
+type Matcher struct {
+	// MinConfidence is the lowest confidence a match can have to be returned by Rank.
+	MinConfidence float64
+	// CertainConfidence is the confidence the best match needs for it to be certain.
+	CertainConfidence float64
+	// AmbiguityMargin is how much more confident the best match needs to be than the next
+	// best match for it to be certain.
+	AmbiguityMargin float64
+}
+
+// New will create a Matcher with the default confidence thresholds.
+func New() *Matcher {
+	return &Matcher{
+		MinConfidence:     DefaultMinConfidence,
+		CertainConfidence: DefaultCertainConfidence,
+		AmbiguityMargin:   DefaultAmbiguityMargin,
+	}
+}
+
+// Rank will score every candidate against the spoken name and return the candidates with at
+// least the MinConfidence, the best match first. Candidates with the same confidence keep
+// the order they were provided in.
+func (m *Matcher) Rank(spoken string, candidates []Candidate) []*Match {
+
+	spokenForms := SpokenForms(spoken)
+	matches := make([]*Match, 0, len(candidates))
+	for _, candidate := range candidates {
+		best := &Match{Candidate: candidate}
+		for _, name := range candidate.Names {
+			confidence := Score(spokenForms, name)
+			if confidence > best.Confidence {
+				best.Name = name
+				best.Confidence = confidence
+			}
+		}
+
+		if best.Confidence >= m.MinConfidence {
+			matches = append(matches, best)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Confidence > matches[j].Confidence
+	})
+
+	return matches
+}
+
+// Certain will return true if the best of the ranked matches is confident enough, and far
+// enough ahead of the next best match, that the user doesn't need to choose between them.
+func (m *Matcher) Certain(matches []*Match) bool {
+	if len(matches) == 0 || matches[0].Confidence < m.CertainConfidence {
+		return false
+	}
+
+	return len(matches) == 1 || matches[0].Confidence-matches[1].Confidence >= m.AmbiguityMargin
+}
+
+// Ambiguous will return the matches that are too close to the best match for the best match
+// to be certain, including the best match. These are the matches the user should choose
+// between. nil is returned if there aren't any matches.
+func (m *Matcher) Ambiguous(matches []*Match) []*Match {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	end := 1
+	for end < len(matches) && matches[0].Confidence-matches[end].Confidence < m.AmbiguityMargin {
+		end++
+	}
+
+	return matches[:end]
+}
+
+// Score will return how well the name matches any of the spoken forms (see SpokenForms),
+// from 0 to 1. An exact match is 1, otherwise the score combines the edit distance of the
+// names with the edit distance of their phonetic encodings. A name that starts with the
+// spoken name is also a partial match, but is never certain.
+func Score(spokenForms []string, name string) float64 {
+
+	best := 0.0
+	for _, nameForm := range NameForms(name) {
+		for _, spokenForm := range spokenForms {
+			if score := scoreForms(spokenForm, nameForm); score > best {
+				best = score
+			}
+		}
+	}
+
+	return best
+}
+
+// scoreForms will score a single spoken form against a single form of the name.
+func scoreForms(spoken, name string) float64 {
+	if spoken == "" || name == "" {
+		return 0
+	} else if spoken == name {
+		return 1
+	}
+
+	phonetic := 0.7*Similarity(Metaphone(spoken), Metaphone(name)) +
+		0.3*Similarity(Soundex(spoken), Soundex(name))
+	score := 0.5*Similarity(spoken, name) + 0.5*phonetic
+	if score > 0.95 {
+		// Only exact matches are certain without any doubt
+		score = 0.95
+	}
+
+	if len(spoken) >= 3 && strings.HasPrefix(name, spoken) {
+		prefix := 0.5 + 0.4*float64(len(spoken))/float64(len(name))
+		if prefix > score {
+			score = prefix
+		}
+	}
+
+	return score
+}
+
+// Similarity will return 1 minus the edit distance between the strings relative to the
+// length of the longer string, so identical strings are 1 and completely different strings
+// are 0.
+func Similarity(a, b string) float64 {
+	longest := len([]rune(a))
+	if length := len([]rune(b)); length > longest {
+		longest = length
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(Levenshtein(a, b))/float64(longest)
+}
+
+// Levenshtein will return the number of single character insertions, deletions and
+// substitutions needed to change a into b.
+func Levenshtein(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
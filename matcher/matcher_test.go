@@ -0,0 +1,143 @@
+package matcher
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+// corpusEntry is a channel in the fixture corpus with the ways speech recognition has
+// returned its name.
+type corpusEntry struct {
+	Login       string   `json:"login"`
+	DisplayName string   `json:"display_name"`
+	Utterances  []string `json:"utterances"`
+}
+
+// loadCorpus will load the fixture corpus and the candidates for every channel in it.
+func loadCorpus(t *testing.T) ([]corpusEntry, []Candidate) {
+	data, err := ioutil.ReadFile("testdata/corpus.json")
+	if err != nil {
+		t.Fatalf("Failed to read the corpus: %s", err.Error())
+	}
+
+	corpus := []corpusEntry{}
+	err = json.Unmarshal(data, &corpus)
+	if err != nil {
+		t.Fatalf("Failed to decode the corpus: %s", err.Error())
+	}
+
+	candidates := make([]Candidate, 0, len(corpus))
+	for _, entry := range corpus {
+		candidates = append(candidates, Candidate{ID: entry.Login, Names: []string{entry.Login, entry.DisplayName}})
+	}
+
+	return corpus, candidates
+}
+
+func TestRankCorpus(t *testing.T) {
+	corpus, candidates := loadCorpus(t)
+	m := New()
+
+	for _, entry := range corpus {
+		for _, utterance := range entry.Utterances {
+			matches := m.Rank(utterance, candidates)
+			if len(matches) == 0 {
+				t.Errorf("%q: expected %s, found no matches", utterance, entry.Login)
+				continue
+			}
+			if matches[0].Candidate.ID != entry.Login {
+				t.Errorf("%q: expected %s, got %s(%.2f)", utterance, entry.Login, matches[0].Candidate.ID,
+					matches[0].Confidence)
+			}
+		}
+	}
+}
+
+func TestRankCertainty(t *testing.T) {
+	_, candidates := loadCorpus(t)
+	m := New()
+
+	cases := map[string]bool{
+		"summit one g":      true,
+		"doctor disrespect": true,
+		"x q c":             false,
+		"lyric":             false,
+	}
+
+	for utterance, certain := range cases {
+		if m.Certain(m.Rank(utterance, candidates)) != certain {
+			t.Errorf("%q: expected certain=%t", utterance, certain)
+		}
+	}
+}
+
+func TestAmbiguous(t *testing.T) {
+	m := New()
+	matches := []*Match{
+		{Candidate: Candidate{ID: "1"}, Confidence: 0.95},
+		{Candidate: Candidate{ID: "2"}, Confidence: 0.9},
+		{Candidate: Candidate{ID: "3"}, Confidence: 0.7},
+	}
+
+	if m.Certain(matches) {
+		t.Fatalf("Matches within the ambiguity margin should not be certain")
+	}
+	if ambiguous := m.Ambiguous(matches); len(ambiguous) != 2 {
+		t.Fatalf("Expected the two closest matches to be ambiguous: %+v", ambiguous)
+	}
+	if m.Ambiguous(nil) != nil {
+		t.Fatalf("Expected no ambiguous matches without any matches")
+	}
+}
+
+func TestSpokenForms(t *testing.T) {
+	cases := map[string]string{
+		"summit one g":      "summit1g",
+		"ex queue see":      "xqc",
+		"quin sixty nine":   "quin69",
+		"doctor disrespect": "drdisrespect",
+		"Lirik_":            "lirik",
+	}
+
+	for spoken, expected := range cases {
+		found := false
+		for _, form := range SpokenForms(spoken) {
+			found = found || form == expected
+		}
+		if !found {
+			t.Errorf("Expected %q in the spoken forms of %q: %v", expected, spoken, SpokenForms(spoken))
+		}
+	}
+}
+
+func TestPhoneticEncodings(t *testing.T) {
+	cases := []struct {
+		name, metaphone, soundex string
+	}{
+		{"lirik", "LRK", "L620"},
+		{"lyric", "LRK", "L620"},
+		{"shroud", "XRT", "S630"},
+		{"knight", "NT", "K523"},
+		{"thegrefg", "0KRFK", "T261"},
+		{"quin69", "KN69", "Q500"},
+	}
+
+	for _, c := range cases {
+		if metaphone := Metaphone(c.name); metaphone != c.metaphone {
+			t.Errorf("Metaphone(%s): expected %s, got %s", c.name, c.metaphone, metaphone)
+		}
+		if soundex := Soundex(c.name); soundex != c.soundex {
+			t.Errorf("Soundex(%s): expected %s, got %s", c.name, c.soundex, soundex)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	if distance := Levenshtein("kitten", "sitting"); distance != 3 {
+		t.Fatalf("Expected a distance of 3, got %d", distance)
+	}
+	if similarity := Similarity("", ""); similarity != 1 {
+		t.Fatalf("Empty strings should be identical, got %f", similarity)
+	}
+}
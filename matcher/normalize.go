@@ -0,0 +1,155 @@
+package matcher
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// numberWords are the spoken numbers that are replaced with digits, including the words
+// speech recognition commonly returns in place of a number.
+var numberWords = map[string]int{
+	"zero": 0, "oh": 0, "one": 1, "won": 1, "two": 2, "to": 2, "too": 2, "three": 3, "four": 4,
+	"for": 4, "five": 5, "six": 6, "seven": 7, "eight": 8, "ate": 8, "nine": 9, "ten": 10,
+	"eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15, "sixteen": 16,
+	"seventeen": 17, "eighteen": 18, "nineteen": 19,
+}
+
+// tensWords are the spoken multiples of ten, they are combined with a following single
+// digit number, e.g. "sixty nine" is 69.
+var tensWords = map[string]int{
+	"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50, "sixty": 60, "seventy": 70,
+	"eighty": 80, "ninety": 90,
+}
+
+// letterWords are the words speech recognition returns when a single letter is spoken.
+var letterWords = map[string]string{
+	"ay": "a", "bee": "b", "be": "b", "see": "c", "sea": "c", "dee": "d", "ee": "e", "ef": "f",
+	"gee": "g", "aitch": "h", "eye": "i", "jay": "j", "kay": "k", "el": "l", "em": "m",
+	"en": "n", "pee": "p", "queue": "q", "cue": "q", "are": "r", "es": "s", "tee": "t",
+	"tea": "t", "you": "u", "vee": "v", "ex": "x", "why": "y", "zee": "z", "zed": "z",
+}
+
+// spokenAbbreviations are words that are usually abbreviated in names.
+var spokenAbbreviations = map[string]string{
+	"doctor": "dr", "mister": "mr", "the": "", "underscore": "", "dot": "",
+}
+
+// leetDigits are the digits that are commonly used in place of a letter in names, e.g.
+// "moistcr1tikal".
+var leetDigits = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't',
+}
+
+// Normalize will lowercase the name and remove everything except letters and numbers, e.g.
+// "Lirik_" and "lirik" are the same name.
+func Normalize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// words will split the spoken name into normalized words.
+func words(spoken string) []string {
+	fields := strings.FieldsFunc(spoken, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	result := make([]string, 0, len(fields))
+	for _, field := range fields {
+		result = append(result, Normalize(field))
+	}
+
+	return result
+}
+
+// SpokenForms will return the different ways a spoken name could be written as a name.
+// Numbers are replaced with digits ("summit one g" is "summit1g"), spoken letters are
+// joined ("x q c" or "ex queue see" is "xqc") and words that are usually abbreviated are
+// shortened ("doctor disrespect" is "drdisrespect"). The first form is always the spoken
+// name with only the spaces and punctuation removed.
+func SpokenForms(spoken string) []string {
+	spokenWords := words(spoken)
+	forms := []string{strings.Join(spokenWords, "")}
+
+	for _, replace := range []struct{ numbers, letters, abbreviations bool }{
+		{numbers: true},
+		{letters: true},
+		{abbreviations: true},
+		{numbers: true, abbreviations: true},
+		{numbers: true, letters: true, abbreviations: true},
+	} {
+		form := joinWords(spokenWords, replace.numbers, replace.letters, replace.abbreviations)
+		forms = appendUnique(forms, form)
+	}
+
+	return forms
+}
+
+// joinWords will join the words into a single name with the selected replacements made.
+func joinWords(spokenWords []string, numbers, letters, abbreviations bool) string {
+	var name strings.Builder
+	for i := 0; i < len(spokenWords); i++ {
+		word := spokenWords[i]
+		if numbers {
+			if tens, ok := tensWords[word]; ok {
+				if i+1 < len(spokenWords) && numberWords[spokenWords[i+1]] > 0 && numberWords[spokenWords[i+1]] < 10 {
+					tens += numberWords[spokenWords[i+1]]
+					i++
+				}
+				name.WriteString(strconv.Itoa(tens))
+				continue
+			} else if number, ok := numberWords[word]; ok {
+				name.WriteString(strconv.Itoa(number))
+				continue
+			}
+		}
+		if letters {
+			if letter, ok := letterWords[word]; ok {
+				name.WriteString(letter)
+				continue
+			}
+		}
+		if abbreviations {
+			if abbreviation, ok := spokenAbbreviations[word]; ok {
+				name.WriteString(abbreviation)
+				continue
+			}
+		}
+		name.WriteString(word)
+	}
+
+	return name.String()
+}
+
+// NameForms will return the normalized name along with the name with any digits used in
+// place of letters replaced, e.g. "moistcr1tikal" is also "moistcritikal".
+func NameForms(name string) []string {
+	normalized := Normalize(name)
+	forms := []string{normalized}
+
+	unleet := strings.Map(func(r rune) rune {
+		if letter, ok := leetDigits[r]; ok {
+			return letter
+		}
+		return r
+	}, normalized)
+
+	return appendUnique(forms, unleet)
+}
+
+func appendUnique(forms []string, form string) []string {
+	if form == "" {
+		return forms
+	}
+	for _, existing := range forms {
+		if existing == form {
+			return forms
+		}
+	}
+
+	return append(forms, form)
+}
@@ -0,0 +1,193 @@
+package matcher
+
+import (
+	"strings"
+)
+
+// isVowel will return true for the vowels in an uppercase name.
+func isVowel(c byte) bool {
+	return c == 'A' || c == 'E' || c == 'I' || c == 'O' || c == 'U'
+}
+
+// Metaphone will encode the name with the original Metaphone algorithm so names that sound
+// alike have the same encoding, e.g. "lirik" and "lyric" are both "LRK". Digits are kept
+// as they are since they are spoken as numbers. The name should already be normalized.
+func Metaphone(name string) string {
+	word := []byte(strings.ToUpper(name))
+	if len(word) == 0 {
+		return ""
+	}
+
+	// Letters that are silent or changed at the start of a word
+	switch {
+	case hasPrefix(word, "KN"), hasPrefix(word, "GN"), hasPrefix(word, "PN"), hasPrefix(word, "AE"),
+		hasPrefix(word, "WR"):
+		word = word[1:]
+	case word[0] == 'X':
+		word[0] = 'S'
+	case hasPrefix(word, "WH"):
+		word = append([]byte{'W'}, word[2:]...)
+	}
+
+	at := func(i int) byte {
+		if i < 0 || i >= len(word) {
+			return 0
+		}
+		return word[i]
+	}
+
+	var code strings.Builder
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		// Duplicate letters are only encoded once, except for C
+		if c != 'C' && i > 0 && c == word[i-1] {
+			continue
+		}
+
+		switch {
+		case c >= '0' && c <= '9':
+			code.WriteByte(c)
+		case isVowel(c):
+			if i == 0 {
+				code.WriteByte(c)
+			}
+		case c == 'B':
+			if !(i == len(word)-1 && at(i-1) == 'M') {
+				code.WriteByte('B')
+			}
+		case c == 'C':
+			switch {
+			case at(i+1) == 'I' && at(i+2) == 'A':
+				code.WriteByte('X')
+			case at(i+1) == 'H':
+				if at(i-1) == 'S' {
+					code.WriteByte('K')
+				} else {
+					code.WriteByte('X')
+				}
+				i++
+			case at(i+1) == 'I' || at(i+1) == 'E' || at(i+1) == 'Y':
+				if at(i-1) != 'S' {
+					code.WriteByte('S')
+				}
+			default:
+				code.WriteByte('K')
+			}
+		case c == 'D':
+			if at(i+1) == 'G' && (at(i+2) == 'E' || at(i+2) == 'Y' || at(i+2) == 'I') {
+				code.WriteByte('J')
+				i++
+			} else {
+				code.WriteByte('T')
+			}
+		case c == 'G':
+			switch {
+			case at(i+1) == 'H' && i+2 < len(word) && !isVowel(at(i+2)):
+				// Silent in words like "night"
+			case at(i+1) == 'N' && (i+2 == len(word) || (at(i+2) == 'E' && at(i+3) == 'D' && i+4 == len(word))):
+				// Silent in words like "sign" and "signed"
+			case (at(i+1) == 'I' || at(i+1) == 'E' || at(i+1) == 'Y') && at(i-1) != 'G':
+				code.WriteByte('J')
+			default:
+				code.WriteByte('K')
+			}
+		case c == 'H':
+			afterVowel := i > 0 && isVowel(at(i-1))
+			silentAfter := strings.IndexByte("CSPTG", at(i-1)) != -1
+			if !silentAfter && !(afterVowel && !isVowel(at(i+1))) {
+				code.WriteByte('H')
+			}
+		case c == 'K':
+			if at(i-1) != 'C' {
+				code.WriteByte('K')
+			}
+		case c == 'P':
+			if at(i+1) == 'H' {
+				code.WriteByte('F')
+			} else {
+				code.WriteByte('P')
+			}
+		case c == 'Q':
+			code.WriteByte('K')
+		case c == 'S':
+			if at(i+1) == 'H' || (at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A')) {
+				code.WriteByte('X')
+			} else {
+				code.WriteByte('S')
+			}
+		case c == 'T':
+			switch {
+			case at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A'):
+				code.WriteByte('X')
+			case at(i+1) == 'H':
+				code.WriteByte('0')
+				i++
+			case at(i+1) == 'C' && at(i+2) == 'H':
+				// Silent in "tch"
+			default:
+				code.WriteByte('T')
+			}
+		case c == 'V':
+			code.WriteByte('F')
+		case c == 'W', c == 'Y':
+			if isVowel(at(i + 1)) {
+				code.WriteByte(c)
+			}
+		case c == 'X':
+			code.WriteString("KS")
+		case c == 'Z':
+			code.WriteByte('S')
+		case c >= 'A' && c <= 'Z':
+			code.WriteByte(c)
+		}
+	}
+
+	return code.String()
+}
+
+func hasPrefix(word []byte, prefix string) bool {
+	return strings.HasPrefix(string(word), prefix)
+}
+
+// soundexCodes are the Soundex digits for each letter, letters without a code are ignored.
+var soundexCodes = map[byte]byte{
+	'B': '1', 'F': '1', 'P': '1', 'V': '1',
+	'C': '2', 'G': '2', 'J': '2', 'K': '2', 'Q': '2', 'S': '2', 'X': '2', 'Z': '2',
+	'D': '3', 'T': '3', 'L': '4', 'M': '5', 'N': '5', 'R': '6',
+}
+
+// Soundex will encode the name with the American Soundex algorithm. Soundex only looks at
+// the start of a name so it is used alongside Metaphone to break ties between names that
+// have similar Metaphone encodings. Digits are ignored.
+func Soundex(name string) string {
+	word := strings.ToUpper(name)
+	code := make([]byte, 0, 4)
+	var last byte
+	for i := 0; i < len(word) && len(code) < 4; i++ {
+		c := word[i]
+		if c < 'A' || c > 'Z' {
+			continue
+		}
+
+		digit := soundexCodes[c]
+		if len(code) == 0 {
+			code = append(code, c)
+		} else if digit != 0 && digit != last {
+			code = append(code, digit)
+		}
+
+		// H and W don't separate letters with the same code, vowels do
+		if c != 'H' && c != 'W' {
+			last = digit
+		}
+	}
+
+	if len(code) == 0 {
+		return ""
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+
+	return string(code)
+}
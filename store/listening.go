@@ -0,0 +1,50 @@
+package store
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ListeningSession is a single stream the user listened to, EndedAt will be nil if the
+// session has not ended.
+type ListeningSession struct {
+	ID           int64
+	StreamUserID string
+	StartedAt    time.Time
+	EndedAt      *time.Time
+}
+
+// RecordListening will record a listening session that has already ended.
+func (s *PostgresStore) RecordListening(userID, streamUserID string, startedAt, endedAt time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO listening_history (user_id, stream_user_id, started_at, ended_at)
+VALUES ($1, $2, $3, $4)`, userID, streamUserID, startedAt, endedAt)
+
+	return err
+}
+
+// ListeningHistory will return the user's most recent listening sessions, newest first.
+func (s *PostgresStore) ListeningHistory(userID string, limit int) ([]*ListeningSession, error) {
+	rows, err := s.db.Query(`SELECT id, stream_user_id, started_at, ended_at FROM listening_history
+WHERE user_id = $1 ORDER BY started_at DESC LIMIT $2`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]*ListeningSession, 0)
+	for rows.Next() {
+		session := &ListeningSession{}
+		var endedAt pq.NullTime
+		err = rows.Scan(&session.ID, &session.StreamUserID, &session.StartedAt, &endedAt)
+		if err != nil {
+			return nil, err
+		}
+		if endedAt.Valid {
+			session.EndedAt = &endedAt.Time
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kpango/glg"
+)
+
+// migrationLockID is the key for the advisory lock held while migrations are applied, so
+// instances starting at the same time don't apply the same migration twice.
+const migrationLockID = 7294310548
+
+// migration is a single, numbered change to the database schema.
+type migration struct {
+	Version     int
+	Description string
+	SQL         string
+}
+
+// migrations are all of the schema changes for the database in the order they need to be
+// applied. New migrations should only ever be appended to this list.
+var migrations = []migration{
+	{
+		Version:     1,
+		Description: "create recent streams and listening history",
+		SQL: `
+CREATE TABLE recent_streams (
+	user_id        TEXT NOT NULL,
+	stream_user_id TEXT NOT NULL,
+	played_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (user_id, stream_user_id)
+);
+CREATE INDEX recent_streams_user_played_idx ON recent_streams (user_id, played_at DESC);
+
+CREATE TABLE listening_history (
+	id             BIGSERIAL PRIMARY KEY,
+	user_id        TEXT NOT NULL,
+	stream_user_id TEXT NOT NULL,
+	started_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+	ended_at       TIMESTAMPTZ
+);
+CREATE INDEX listening_history_user_started_idx ON listening_history (user_id, started_at DESC);`,
+	},
+}
+
+// LatestVersion is the schema version the database will be at once every migration has
+// been applied.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// ensureMigrationsTable will create the table used to track the applied migrations.
+func (s *PostgresStore) ensureMigrationsTable() error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER PRIMARY KEY,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`)
+
+	return err
+}
+
+// SchemaVersion will return the version of the most recent migration applied to the
+// database, or 0 if no migrations have been applied.
+func (s *PostgresStore) SchemaVersion() (int, error) {
+	err := s.ensureMigrationsTable()
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	err = s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+
+	return version, err
+}
+
+// CheckMigrations will return an error if the database schema is not at the latest version.
+func (s *PostgresStore) CheckMigrations() error {
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if version != LatestVersion() {
+		return fmt.Errorf("database schema is at version %d, expected version %d", version, LatestVersion())
+	}
+
+	return nil
+}
+
+// Migrate will apply every migration that has not been applied to the database yet. Each
+// migration is applied in its own transaction along with the record that it was applied. An
+// advisory lock is held from reading the schema version until the last migration is applied
+// so only one instance migrates the database at a time.
+func (s *PostgresStore) Migrate() error {
+	ctx := context.Background()
+
+	// The advisory lock belongs to a session so it has to be taken and released on the same
+	// connection, the migrations themselves can use any connection from the pool.
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID)
+	if err != nil {
+		return fmt.Errorf("failed to lock the database for migrations: %s", err.Error())
+	}
+	defer func() {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockID)
+		if err != nil {
+			glg.Warnf("Failed to release the database migration lock: %s", err.Error())
+		}
+	}()
+
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= version {
+			continue
+		}
+
+		glg.Infof("Applying database migration %d: %s", m.Version, m.Description)
+		err = s.applyMigration(m)
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d: %s", m.Version, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) applyMigration(m migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(m.SQL)
+	if err == nil {
+		_, err = tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", m.Version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
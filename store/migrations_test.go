@@ -0,0 +1,21 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestMigrationVersionsIncrease(t *testing.T) {
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("Migration %d has version %d, versions should start at 1 and increase by 1",
+				i, m.Version)
+		}
+		if m.SQL == "" || m.Description == "" {
+			t.Errorf("Migration %d is missing its SQL or description", m.Version)
+		}
+	}
+
+	if LatestVersion() != len(migrations) {
+		t.Errorf("Expected latest version %d, got %d", len(migrations), LatestVersion())
+	}
+}
@@ -0,0 +1,199 @@
+// Package store provides long-lived storage for the skill's data (recent streams and
+// listening history) backed by PostgreSQL.
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	// Register the postgres driver with database/sql
+	_ "github.com/lib/pq"
+)
+
+// PostgresStore keeps the skill's data in a PostgreSQL database. It implements
+// twitch.HistoryStore so it can be used as the persistent store behind the Redis history.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// Open will connect to the PostgreSQL database at the provided URL (e.g. the DATABASE_URL
+// provided by Heroku) and verify the connection.
+func Open(databaseURL string) (*PostgresStore, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep this below the connection limit for the Heroku Postgres hobby tier
+	db.SetMaxOpenConns(10)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &PostgresStore{db: db}, nil
+}
+
+// Close will close all of the connections to the database.
+func (s *PostgresStore) Close() error {
+	return s.db.Close()
+}
+
+// Push will make the stream the user's current stream.
+func (s *PostgresStore) Push(userID, streamUserID string) error {
+	_, err := s.db.Exec(`INSERT INTO recent_streams (user_id, stream_user_id, played_at)
+VALUES ($1, $2, clock_timestamp())
+ON CONFLICT (user_id, stream_user_id) DO UPDATE SET played_at = EXCLUDED.played_at`,
+		userID, streamUserID)
+
+	return err
+}
+
+// Current will return the user's current stream, or the empty string if there is none.
+func (s *PostgresStore) Current(userID string) (string, error) {
+	var streamUserID string
+	err := s.db.QueryRow(`SELECT stream_user_id FROM recent_streams WHERE user_id = $1
+ORDER BY played_at DESC LIMIT 1`, userID).Scan(&streamUserID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return streamUserID, err
+}
+
+// Pop will remove the user's current stream and return the new current stream. The user's
+// rows are locked for the duration of the transaction so concurrent pops for the same user
+// each remove a different stream.
+func (s *PostgresStore) Pop(userID string) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// Every row is locked rather than just the first two, a LIMIT with FOR UPDATE can return
+	// too few rows when a locked row is deleted by a concurrent transaction.
+	rows, err := tx.Query(`SELECT stream_user_id FROM recent_streams WHERE user_id = $1
+ORDER BY played_at DESC FOR UPDATE`, userID)
+	if err != nil {
+		return "", err
+	}
+
+	streams := make([]string, 0, 2)
+	for len(streams) < 2 && rows.Next() {
+		var streamUserID string
+		err = rows.Scan(&streamUserID)
+		if err != nil {
+			rows.Close()
+			return "", err
+		}
+		streams = append(streams, streamUserID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	if len(streams) == 0 {
+		return "", nil
+	}
+
+	_, err = tx.Exec("DELETE FROM recent_streams WHERE user_id = $1 AND stream_user_id = $2",
+		userID, streams[0])
+	if err != nil {
+		return "", err
+	}
+
+	current := ""
+	if len(streams) > 1 {
+		current = streams[1]
+	}
+
+	return current, tx.Commit()
+}
+
+// Previous will remove the user's current stream and any earlier streams that aren't live,
+// returning the new current stream. The user's rows are locked for the duration of the
+// transaction so concurrent requests for the same user are applied one at a time.
+func (s *PostgresStore) Previous(userID string, liveStreamUserIDs []string) (string, error) {
+	live := make(map[string]bool, len(liveStreamUserIDs))
+	for _, uid := range liveStreamUserIDs {
+		live[uid] = true
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT stream_user_id FROM recent_streams WHERE user_id = $1
+ORDER BY played_at DESC FOR UPDATE`, userID)
+	if err != nil {
+		return "", err
+	}
+
+	stale := make([]string, 0)
+	current := ""
+	for rows.Next() {
+		var streamUserID string
+		err = rows.Scan(&streamUserID)
+		if err != nil {
+			rows.Close()
+			return "", err
+		}
+
+		// The first row is always the current stream which is being removed
+		if len(stale) > 0 && live[streamUserID] {
+			current = streamUserID
+			break
+		}
+		stale = append(stale, streamUserID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+
+	for _, streamUserID := range stale {
+		_, err = tx.Exec("DELETE FROM recent_streams WHERE user_id = $1 AND stream_user_id = $2",
+			userID, streamUserID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return current, tx.Commit()
+}
+
+// List will return the user's history, most recently played first.
+func (s *PostgresStore) List(userID string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT stream_user_id FROM recent_streams WHERE user_id = $1
+ORDER BY played_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	streams := make([]string, 0)
+	for rows.Next() {
+		var streamUserID string
+		err = rows.Scan(&streamUserID)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, streamUserID)
+	}
+
+	return streams, rows.Err()
+}
+
+// Clear will remove the user's entire recent streams history.
+func (s *PostgresStore) Clear(userID string) error {
+	_, err := s.db.Exec("DELETE FROM recent_streams WHERE user_id = $1", userID)
+
+	return err
+}
@@ -0,0 +1,222 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestPostgresStore will open and migrate the database at TEST_DATABASE_URL, the test is
+// skipped if it isn't set.
+func newTestPostgresStore(t *testing.T) *PostgresStore {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	store, err := Open(databaseURL)
+	if err != nil {
+		t.Fatalf("Failed to open test database: %s", err.Error())
+	}
+
+	err = store.Migrate()
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %s", err.Error())
+	}
+
+	return store
+}
+
+func randomID() string {
+	return fmt.Sprintf("%d", rand.Int63())
+}
+
+func TestPostgresMigrate(t *testing.T) {
+	store := newTestPostgresStore(t)
+	defer store.Close()
+
+	// Migrating an up to date database should be a no-op
+	err := store.Migrate()
+	if err != nil {
+		t.Fatalf("Failed to migrate an up to date database: %s", err.Error())
+	}
+
+	err = store.CheckMigrations()
+	if err != nil {
+		t.Fatalf("Database should be at the latest version: %s", err.Error())
+	}
+}
+
+func TestPostgresMigrateConcurrently(t *testing.T) {
+	store := newTestPostgresStore(t)
+	defer store.Close()
+
+	_, err := store.db.Exec(`DROP TABLE recent_streams, listening_history, schema_migrations`)
+	if err != nil {
+		t.Fatalf("Failed to reset the test database: %s", err.Error())
+	}
+
+	// Without the migration lock these would all try to create the same tables
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := store.Migrate()
+			if err != nil {
+				t.Errorf("Failed to migrate concurrently: %s", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	err = store.CheckMigrations()
+	if err != nil {
+		t.Fatalf("Database should be at the latest version: %s", err.Error())
+	}
+}
+
+func TestPostgresHistory(t *testing.T) {
+	store := newTestPostgresStore(t)
+	defer store.Close()
+
+	userID := randomID()
+	for _, streamUserID := range []string{"1", "2", "3", "2"} {
+		err := store.Push(userID, streamUserID)
+		if err != nil {
+			t.Fatalf("Failed to push recent stream: %s", err.Error())
+		}
+	}
+
+	list, err := store.List(userID)
+	if err != nil {
+		t.Fatalf("Failed to list recent streams: %s", err.Error())
+	}
+	if fmt.Sprint(list) != "[2 3 1]" {
+		t.Fatalf("Unexpected recent streams: %v", list)
+	}
+
+	current, err := store.Pop(userID)
+	if err != nil || current != "3" {
+		t.Fatalf("Expected current stream 3 after pop, got %s (err=%v)", current, err)
+	}
+
+	err = store.Clear(userID)
+	if err != nil {
+		t.Fatalf("Failed to clear recent streams: %s", err.Error())
+	}
+	current, err = store.Current(userID)
+	if err != nil || current != "" {
+		t.Fatalf("Expected no current stream after clear, got %s (err=%v)", current, err)
+	}
+}
+
+func TestPostgresListeningHistory(t *testing.T) {
+	store := newTestPostgresStore(t)
+	defer store.Close()
+
+	userID := randomID()
+	started := time.Now().Add(-time.Hour).Round(time.Second)
+	err := store.RecordListening(userID, "42", started, started.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("Failed to record listening session: %s", err.Error())
+	}
+
+	sessions, err := store.ListeningHistory(userID, 10)
+	if err != nil {
+		t.Fatalf("Failed to load listening history: %s", err.Error())
+	}
+	if len(sessions) != 1 || sessions[0].EndedAt == nil || !sessions[0].StartedAt.Equal(started) {
+		t.Fatalf("Unexpected listening history: %+v", sessions)
+	}
+}
+
+func TestPostgresPopConcurrently(t *testing.T) {
+	store := newTestPostgresStore(t)
+	defer store.Close()
+
+	userID := randomID()
+	for i := 0; i < 11; i++ {
+		err := store.Push(userID, fmt.Sprintf("%d", i))
+		if err != nil {
+			t.Fatalf("Failed to push recent stream: %s", err.Error())
+		}
+	}
+
+	results := make(chan string, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			current, err := store.Pop(userID)
+			if err != nil {
+				t.Errorf("Failed to pop the current stream: %s", err.Error())
+			}
+			results <- current
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	seen := make(map[string]bool)
+	for current := range results {
+		if current == "" || seen[current] {
+			t.Fatalf("Concurrent pops returned a duplicate or empty stream: %q", current)
+		}
+		seen[current] = true
+	}
+
+	current, err := store.Pop(userID)
+	if err != nil || current != "" {
+		t.Fatalf("Expected the history to be empty, got %s (err=%v)", current, err)
+	}
+}
+
+func TestPostgresPreviousConcurrently(t *testing.T) {
+	store := newTestPostgresStore(t)
+	defer store.Close()
+
+	userID := randomID()
+	live := []string{}
+	for i := 0; i < 11; i++ {
+		streamUserID := fmt.Sprintf("%d", i)
+		err := store.Push(userID, streamUserID)
+		if err != nil {
+			t.Fatalf("Failed to push recent stream: %s", err.Error())
+		}
+		live = append(live, streamUserID)
+	}
+
+	results := make(chan string, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			previous, err := store.Previous(userID, live)
+			if err != nil {
+				t.Errorf("Failed to move to the previous stream: %s", err.Error())
+			}
+			results <- previous
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	seen := make(map[string]bool)
+	for previous := range results {
+		if previous == "" || seen[previous] {
+			t.Fatalf("Concurrent previous calls returned a duplicate or empty stream: %q", previous)
+		}
+		seen[previous] = true
+	}
+
+	previous, err := store.Previous(userID, live)
+	if err != nil || previous != "" {
+		t.Fatalf("Expected nothing previous to be live, got %s (err=%v)", previous, err)
+	}
+}
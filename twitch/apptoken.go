@@ -0,0 +1,180 @@
+package twitch
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AppTokenPath is the path used to request an app access token with the client
+// credentials grant.
+const AppTokenPath = "/token"
+
+// DefaultAppTokenRefreshWindow is how long before an app access token expires that it
+// will be refreshed.
+const DefaultAppTokenRefreshWindow = 5 * time.Minute
+
+// tokenPolicy describes which access token should be sent with a request to an endpoint.
+type tokenPolicy int
+
+const (
+	// noToken requests only send the Client-ID header.
+	noToken tokenPolicy = iota
+	// appToken requests are sent with the app access token.
+	appToken
+	// userOrAppToken requests are sent with the user's token when there is one, otherwise
+	// the app access token is used.
+	userOrAppToken
+	// userToken requests must be sent with the user's token.
+	userToken
+)
+
+// appTokenResponse is the response from the client credentials grant.
+type appTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+// AppTokenSource provides app access tokens for a Client using the client credentials grant.
+// The token is cached and refreshed shortly before it expires. Concurrent callers share a
+// single refresh request.
+type AppTokenSource struct {
+	// ClientSecret is the secret for the Twitch application.
+	ClientSecret string
+	// RefreshWindow is how long before expiry the token will be refreshed.
+	RefreshWindow time.Duration
+
+	mutex     sync.Mutex
+	token     string
+	expiresAt time.Time
+	refresh   *appTokenRefresh
+	now       func() time.Time
+}
+
+// appTokenRefresh is a request for a new app access token that is in progress. Callers
+// arriving during the request wait for done to be closed and share its result.
+type appTokenRefresh struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// NewAppTokenSource will create a new AppTokenSource for the application with the provided
+// client secret.
+func NewAppTokenSource(clientSecret string) *AppTokenSource {
+	return &AppTokenSource{
+		ClientSecret:  clientSecret,
+		RefreshWindow: DefaultAppTokenRefreshWindow,
+		now:           time.Now,
+	}
+}
+
+// Invalidate will drop the cached token if it matches the provided token so that the next
+// request will get a new one. This should be used when Twitch rejects the token.
+func (source *AppTokenSource) Invalidate(token string) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.token == token {
+		source.token = ""
+	}
+}
+
+// AppToken will return a valid app access token, requesting a new one if there is no
+// cached token or the cached token is about to expire.
+func (c *Client) AppToken() (string, error) {
+	source := c.AppTokens
+	if source == nil {
+		return "", nil
+	}
+
+	source.mutex.Lock()
+	now := source.now()
+	if source.token != "" && now.Add(source.RefreshWindow).Before(source.expiresAt) {
+		token := source.token
+		source.mutex.Unlock()
+		return token, nil
+	}
+
+	// The lock isn't held during the request since a rejected request invalidates tokens
+	// through the same source. Concurrent callers wait for and share a single request.
+	refresh := source.refresh
+	if refresh != nil {
+		source.mutex.Unlock()
+		<-refresh.done
+		return refresh.token, refresh.err
+	}
+	refresh = &appTokenRefresh{done: make(chan struct{})}
+	source.refresh = refresh
+	source.mutex.Unlock()
+
+	tokenJSON, err := c.requestAppToken(source)
+
+	source.mutex.Lock()
+	source.refresh = nil
+	if err == nil {
+		source.token = tokenJSON.AccessToken
+		source.expiresAt = now.Add(time.Duration(tokenJSON.ExpiresIn) * time.Second)
+		c.Logger.Debugf("Refreshed app access token, expires at: %v", source.expiresAt)
+		refresh.token = tokenJSON.AccessToken
+	}
+	refresh.err = err
+	source.mutex.Unlock()
+	close(refresh.done)
+
+	return refresh.token, refresh.err
+}
+
+// requestAppToken will request a new app access token with the client credentials grant.
+// The credentials are sent in the form encoded body rather than the URL so the secret can't
+// end up in a logged *url.Error.
+func (c *Client) requestAppToken(source *AppTokenSource) (*appTokenResponse, error) {
+	form := url.Values{}
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", source.ClientSecret)
+	form.Set("grant_type", "client_credentials")
+
+	req, err := http.NewRequest("POST", c.AuthBaseURL+AppTokenPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, &Error{Kind: ErrUnknown, Op: "get app access token", Err: err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	tokenJSON := &appTokenResponse{}
+	err = c.decodeResponse("get app access token", req, tokenJSON)
+	if err != nil {
+		if twitchErr, ok := err.(*Error); ok && twitchErr.Kind != ErrUpstream &&
+			twitchErr.Kind != ErrRateLimited {
+			// The user can't fix a problem with the app credentials by linking their
+			// account again so these should not be reported as an auth problem.
+			twitchErr.Kind = ErrUnknown
+		}
+		return nil, err
+	}
+
+	return tokenJSON, nil
+}
+
+// tokenFor will return the access token that should be sent for an endpoint with the
+// provided policy. The empty string is returned if no token should be sent.
+func (c *Client) tokenFor(policy tokenPolicy, userAccessToken string) (string, error) {
+	switch policy {
+	case userToken:
+		if userAccessToken == "" {
+			return "", &Error{Kind: ErrUnauthorized, Op: "authorize request", Message: "no user access token"}
+		}
+		return userAccessToken, nil
+	case userOrAppToken:
+		if userAccessToken != "" {
+			return userAccessToken, nil
+		}
+		return c.AppToken()
+	case appToken:
+		return c.AppToken()
+	}
+
+	return "", nil
+}
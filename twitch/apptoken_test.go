@@ -0,0 +1,157 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newAppTokenServer will serve app access tokens on the token path (numbered by request)
+// and record the Authorization header sent to every other path.
+func newAppTokenServer(t *testing.T, tokenRequests *int32, auth chan string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == AppTokenPath {
+			r.ParseForm()
+			if r.Method != "POST" || r.PostForm.Get("grant_type") != "client_credentials" ||
+				r.PostForm.Get("client_secret") != "secret" || r.URL.RawQuery != "" {
+				t.Errorf("Invalid app token request: %s %s", r.Method, r.URL.String())
+			}
+			count := atomic.AddInt32(tokenRequests, 1)
+			// Give concurrent callers a chance to pile up behind this request
+			time.Sleep(10 * time.Millisecond)
+			fmt.Fprintf(w, `{"access_token":"app%d","expires_in":3600,"token_type":"bearer"}`, count)
+			return
+		}
+
+		auth <- r.Header.Get("Authorization")
+		w.Write([]byte(`{"data":[{"id":"1"}]}`))
+	}))
+}
+
+func TestAppTokenSharedRefresh(t *testing.T) {
+	var tokenRequests int32
+	server := newAppTokenServer(t, &tokenRequests, nil)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.AuthBaseURL = server.URL
+	client.AppTokens = NewAppTokenSource("secret")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := client.AppToken()
+			if err != nil || token != "app1" {
+				t.Errorf("Incorrect app token(%s): %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if tokenRequests != 1 {
+		t.Fatalf("Expected concurrent callers to share one refresh, made %d requests", tokenRequests)
+	}
+}
+
+func TestAppTokenRefreshedBeforeExpiry(t *testing.T) {
+	var tokenRequests int32
+	server := newAppTokenServer(t, &tokenRequests, nil)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.AuthBaseURL = server.URL
+	client.AppTokens = NewAppTokenSource("secret")
+
+	client.AppToken()
+	now := time.Now().Add(time.Hour - time.Minute)
+	client.AppTokens.now = func() time.Time { return now }
+
+	token, _ := client.AppToken()
+	if token != "app2" || tokenRequests != 2 {
+		t.Fatalf("Expected the token to be refreshed inside the refresh window, got %s", token)
+	}
+
+	client.AppTokens.Invalidate("app2")
+	token, _ = client.AppToken()
+	if token != "app3" {
+		t.Fatalf("Expected a new token after invalidating, got %s", token)
+	}
+}
+
+func TestAppTokenRejectedCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"status":401,"message":"invalid client secret"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.AuthBaseURL = server.URL
+	client.AppTokens = NewAppTokenSource("wrong")
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := client.AppToken()
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if err == nil || KindOf(err) != ErrUnknown {
+			t.Fatalf("Expected rejected app credentials to be an unknown error, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Requesting an app token with rejected credentials never returned")
+	}
+}
+
+func TestAppTokenSecretNotInError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	client := NewClient("test")
+	client.AuthBaseURL = server.URL
+	client.AppTokens = NewAppTokenSource("supersecret")
+
+	_, err := client.AppToken()
+	if err == nil {
+		t.Fatal("Expected an error requesting an app token from a closed server")
+	}
+	if strings.Contains(err.Error(), "supersecret") {
+		t.Fatalf("App token request error contains the client secret: %s", err.Error())
+	}
+}
+
+func TestClientChoosesTokenPerEndpoint(t *testing.T) {
+	var tokenRequests int32
+	auth := make(chan string, 1)
+	server := newAppTokenServer(t, &tokenRequests, auth)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+	client.AuthBaseURL = server.URL
+	client.AppTokens = NewAppTokenSource("secret")
+
+	client.FindLiveStreams([]string{"1"})
+	if header := <-auth; header != "Bearer app1" {
+		t.Fatalf("Expected live streams to use the app token, got: %s", header)
+	}
+
+	client.GetUserByID("user-token", "1")
+	if header := <-auth; header != "Bearer user-token" {
+		t.Fatalf("Expected get user to use the user token, got: %s", header)
+	}
+
+	client.GetUserByID("", "1")
+	if header := <-auth; header != "Bearer app1" {
+		t.Fatalf("Expected get user without a user token to use the app token, got: %s", header)
+	}
+}
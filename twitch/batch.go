@@ -0,0 +1,129 @@
+package twitch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaxIDsPerRequest is the maximum number of IDs Helix will accept in a single request
+// for endpoints that take a list of IDs (e.g. user_id on the streams endpoint).
+const MaxIDsPerRequest = 100
+
+// DefaultMaxConcurrentRequests is the number of batched requests that will be in flight
+// at once, unless the Client is configured otherwise.
+const DefaultMaxConcurrentRequests = 4
+
+// BatchError is returned when some, but not all, of the batches in a batched request
+// failed. The results from the successful batches are still returned with this error.
+type BatchError struct {
+	// Total is the number of batches that were requested.
+	Total int
+	// Errors contains the error for each batch that failed, keyed by batch index.
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for i := 0; i < e.Total; i++ {
+		if err, ok := e.Errors[i]; ok {
+			msgs = append(msgs, fmt.Sprintf("batch %d: %s", i, err.Error()))
+		}
+	}
+
+	return fmt.Sprintf("%d of %d batches failed: %s", len(e.Errors), e.Total,
+		strings.Join(msgs, "; "))
+}
+
+// chunkIDs will split the provided IDs into slices with at most size IDs each.
+func chunkIDs(ids []string, size int) [][]string {
+	chunks := make([][]string, 0, (len(ids)+size-1)/size)
+	for size < len(ids) {
+		ids, chunks = ids[size:], append(chunks, ids[0:size:size])
+	}
+
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+
+	return chunks
+}
+
+// FindLiveStreams will request the data for all currently live streams on Twitch for the
+// provided list of user IDs. The IDs are split into batches that Helix will accept and
+// the batches are requested concurrently. The merged results are ordered by viewer count
+// (highest first), matching the ordering Helix uses for a single request.
+//
+// If only some of the batches fail, or a batch was truncated by the rate limiter, the streams
+// that were loaded are returned along with a *BatchError describing the failures.
+func (c *Client) FindLiveStreams(uids []string) (*StreamsResponse, error) {
+
+	chunks := chunkIDs(uids, MaxIDsPerRequest)
+	results := make([]*StreamsResponse, len(chunks))
+	errs := make(map[int]error)
+
+	workers := c.MaxConcurrentRequests
+	if workers <= 0 {
+		workers = DefaultMaxConcurrentRequests
+	}
+	semaphore := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(index int, ids []string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			// A truncated batch still has results for the pages that were loaded
+			result, err := c.findLiveStreamsBatch(ids)
+			if err != nil {
+				mutex.Lock()
+				errs[index] = err
+				mutex.Unlock()
+			}
+			if _, truncated := err.(*TruncatedError); err == nil || truncated {
+				results[index] = result
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	if len(chunks) > 0 && len(errs) == len(chunks) && !hasResults(results) {
+		return nil, errs[0]
+	}
+
+	merged := &StreamsResponse{Data: make([]*Stream, 0)}
+	for _, result := range results {
+		if result != nil {
+			merged.Data = append(merged.Data, result.Data...)
+		}
+	}
+	sort.SliceStable(merged.Data, func(i, j int) bool {
+		return merged.Data[i].ViewerCount > merged.Data[j].ViewerCount
+	})
+
+	c.Logger.Debugf("Found %d live streams in %d batches", len(merged.Data), len(chunks))
+
+	if len(errs) > 0 {
+		batchErr := &BatchError{Total: len(chunks), Errors: errs}
+		c.Logger.Warnf("Partial failure loading live streams: %s", batchErr.Error())
+		return merged, batchErr
+	}
+
+	return merged, nil
+}
+
+// hasResults will return true if any of the batches returned results.
+func hasResults(results []*StreamsResponse) bool {
+	for _, result := range results {
+		if result != nil {
+			return true
+		}
+	}
+
+	return false
+}
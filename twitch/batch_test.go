@@ -0,0 +1,130 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func makeIDs(count int) []string {
+	ids := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		ids = append(ids, strconv.Itoa(i))
+	}
+
+	return ids
+}
+
+func TestChunkIDs(t *testing.T) {
+	cases := []struct {
+		count    int
+		expected []int
+	}{
+		{0, []int{}},
+		{1, []int{1}},
+		{100, []int{100}},
+		{101, []int{100, 1}},
+		{250, []int{100, 100, 50}},
+	}
+
+	for _, c := range cases {
+		chunks := chunkIDs(makeIDs(c.count), MaxIDsPerRequest)
+		if len(chunks) != len(c.expected) {
+			t.Fatalf("Incorrect number of chunks for %d IDs: %d", c.count, len(chunks))
+		}
+		for i, chunk := range chunks {
+			if len(chunk) != c.expected[i] {
+				t.Fatalf("Incorrect chunk size for %d IDs, chunk %d: %d", c.count, i, len(chunk))
+			}
+		}
+	}
+}
+
+// newLiveStreamsServer will return every requested user ID as a live stream with a viewer
+// count equal to the ID. Any batch containing the failID will return invalid JSON.
+func newLiveStreamsServer(t *testing.T, failID string, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		ids := r.URL.Query()["user_id"]
+		if len(ids) > MaxIDsPerRequest {
+			t.Errorf("Too many user IDs in a single request: %d", len(ids))
+		}
+
+		data := ""
+		for i, id := range ids {
+			if id == failID {
+				w.Write([]byte("{not json"))
+				return
+			}
+			if i > 0 {
+				data += ","
+			}
+			data += fmt.Sprintf(`{"user_id":"%s","viewer_count":%s}`, id, id)
+		}
+		fmt.Fprintf(w, `{"data":[%s]}`, data)
+	}))
+}
+
+func TestFindLiveStreamsBatchesAndMerges(t *testing.T) {
+	var requests int32
+	server := newLiveStreamsServer(t, "", &requests)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	streams, err := client.FindLiveStreams(makeIDs(250))
+	if err != nil {
+		t.Fatalf("Unexpected error finding live streams: %s", err.Error())
+	}
+
+	if requests != 3 || len(streams.Data) != 250 {
+		t.Fatalf("Incorrect batching, requests=%d streams=%d", requests, len(streams.Data))
+	}
+
+	for i, stream := range streams.Data {
+		if stream.ViewerCount != 249-i {
+			t.Fatalf("Merged streams are not ordered by viewer count at index %d: %d", i, stream.ViewerCount)
+		}
+	}
+}
+
+func TestFindLiveStreamsReportsPartialFailure(t *testing.T) {
+	var requests int32
+	server := newLiveStreamsServer(t, "150", &requests)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	streams, err := client.FindLiveStreams(makeIDs(250))
+	batchErr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("Expected a BatchError for a partial failure, got: %v", err)
+	}
+
+	if batchErr.Total != 3 || len(batchErr.Errors) != 1 || batchErr.Errors[1] == nil {
+		t.Fatalf("Incorrect batch error details: %+v", batchErr)
+	}
+
+	if streams == nil || len(streams.Data) != 150 {
+		t.Fatalf("Expected the streams from the successful batches to be returned")
+	}
+}
+
+func TestFindLiveStreamsAllBatchesFail(t *testing.T) {
+	var requests int32
+	server := newLiveStreamsServer(t, "0", &requests)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	streams, err := client.FindLiveStreams(makeIDs(10))
+	if err == nil || streams != nil {
+		t.Fatalf("Expected an error and no streams when every batch fails")
+	}
+}
@@ -0,0 +1,178 @@
+package twitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/kpango/glg"
+)
+
+// The default base URLs used when a Client is created with NewClient. These can be
+// overridden on the Client to point the package at a different server (e.g. a local
+// stand-in server for testing or staging).
+const (
+	DefaultAPIBaseURL   = "https://api.twitch.tv/helix"
+	DefaultUsherBaseURL = "https://usher.ttvnw.net"
+)
+
+// Client is responsible for making requests to the Twitch APIs on behalf of a single
+// Twitch application (client ID). Multiple clients can be used side by side with
+// different client IDs or base URLs.
+type Client struct {
+	// ClientID is the Twitch application client ID sent with every request.
+	ClientID string
+	// APIBaseURL is the base URL for the Helix API, without a trailing slash.
+	APIBaseURL string
+	// AuthBaseURL is the base URL for the Twitch OAuth endpoints.
+	AuthBaseURL string
+	// UsherBaseURL is the base URL used to load the HLS playlists for a stream.
+	UsherBaseURL string
+	// HTTPClient is the transport used for all requests.
+	HTTPClient *http.Client
+	// MaxPages is the upper bound on the number of pages requested from list endpoints.
+	MaxPages int
+	// MaxConcurrentRequests is the number of batched requests that can be in flight at once.
+	MaxConcurrentRequests int
+	// PlaybackTokens provides the tokens needed to load stream playlists from usher.
+	PlaybackTokens PlaybackTokenProvider
+	// AppTokens provides the app access token for endpoints that don't need the user's
+	// token. If nil, those requests are sent with only the Client-ID header.
+	AppTokens *AppTokenSource
+	// LegacyFollowsFallback enables the retired users/follows lookup for linked accounts
+	// that were not granted the user:read:follows scope.
+	LegacyFollowsFallback bool
+	// Tokens caches the results of validating user access tokens, if nil every
+	// validation will be sent to Twitch.
+	Tokens *TokenCache
+	// Games caches the results of game lookups, if nil every lookup will be sent to Twitch.
+	Games *GameCache
+	// RateLimiter tracks the Helix rate limits, if nil no rate limiting is done.
+	RateLimiter *RateLimiter
+	// Logger is where all of the client's log output will be written.
+	Logger *glg.Glg
+}
+
+// NewClient will create a new Twitch API client for the provided client ID that is
+// configured to use the production Twitch URLs.
+func NewClient(clientID string) *Client {
+	httpClient := &http.Client{}
+	return &Client{
+		ClientID:              clientID,
+		APIBaseURL:            DefaultAPIBaseURL,
+		AuthBaseURL:           DefaultAuthBaseURL,
+		UsherBaseURL:          DefaultUsherBaseURL,
+		HTTPClient:            httpClient,
+		PlaybackTokens:        NewGQLPlaybackTokenProvider(httpClient),
+		MaxPages:              DefaultMaxPages,
+		MaxConcurrentRequests: DefaultMaxConcurrentRequests,
+		Tokens:                NewTokenCache(),
+		Games:                 NewGameCache(),
+		RateLimiter:           NewRateLimiter(),
+		Logger:                glg.Get(),
+	}
+}
+
+// newRequest will create a new GET request for the provided URL with the client ID
+// header set. If an access token is provided it will be sent as a bearer token.
+func (c *Client) newRequest(url, accessToken string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Client-ID", c.ClientID)
+	if accessToken != "" {
+		req.Header.Add("Authorization", "Bearer "+accessToken)
+	}
+
+	return req, nil
+}
+
+// do will send the request and return the response if Twitch responded with a successful
+// status code. Any other response, or a failure to reach Twitch, is returned as an *Error.
+// The caller is responsible for closing the body of a successful response.
+func (c *Client) do(op string, req *http.Request) (*http.Response, error) {
+	return c.doWithPriority(op, req, true)
+}
+
+// doWithPriority is the same as do but allows the request to be marked as non-critical.
+// Non-critical requests are shed by the rate limiter when the remaining budget is low.
+func (c *Client) doWithPriority(op string, req *http.Request, critical bool) (*http.Response, error) {
+	if c.RateLimiter != nil {
+		err := c.RateLimiter.reserve(op, req, critical)
+		if err != nil {
+			c.Logger.Warnf("Rate limiter blocked request: %s", err.Error())
+			return nil, err
+		}
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		c.Logger.Errorf("Failed to read the %s response from Twitch!: %s", op, err.Error())
+		return nil, &Error{Kind: ErrUpstream, Op: op, Err: err}
+	}
+
+	if c.RateLimiter != nil {
+		c.RateLimiter.update(req, resp)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		twitchErr := newStatusError(op, resp)
+		c.Logger.Errorf("Got error response from Twitch: %s", twitchErr.Error())
+		if twitchErr.Kind == ErrUnauthorized {
+			// The token was rejected so any cached copy can't be trusted anymore
+			token := requestToken(req)
+			if c.Tokens != nil && token != "" {
+				c.Tokens.Remove(token)
+			}
+			if c.AppTokens != nil && token != "" {
+				c.AppTokens.Invalidate(token)
+			}
+		}
+		return nil, twitchErr
+	}
+
+	return resp, nil
+}
+
+// requestToken will return the access token sent in the Authorization header of the request
+// without the Bearer or OAuth prefix. The empty string is returned if no token was sent.
+func requestToken(req *http.Request) string {
+	auth := req.Header.Get("Authorization")
+	for _, prefix := range []string{"Bearer ", "OAuth "} {
+		if strings.HasPrefix(auth, prefix) {
+			return strings.TrimPrefix(auth, prefix)
+		}
+	}
+
+	return auth
+}
+
+// getJSON will request the provided URL and decode the JSON response into result.
+func (c *Client) getJSON(op, url, accessToken string, result interface{}) error {
+	req, err := c.newRequest(url, accessToken)
+	if err != nil {
+		return &Error{Kind: ErrUnknown, Op: op, Err: err}
+	}
+
+	return c.decodeResponse(op, req, result)
+}
+
+// decodeResponse will send the request and decode the JSON response into result.
+func (c *Client) decodeResponse(op string, req *http.Request, result interface{}) error {
+	resp, err := c.do(op, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		c.Logger.Errorf("Failed to decode Twitch %s JSON: %s", op, err.Error())
+		return newDecodeError(op, err)
+	}
+
+	return nil
+}
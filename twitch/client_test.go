@@ -0,0 +1,59 @@
+package twitch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientUsesConfiguredBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users" {
+			t.Errorf("Unexpected request path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Client-ID") != "test-client-id" {
+			t.Errorf("Incorrect Client-ID header: %s", r.Header.Get("Client-ID"))
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Incorrect Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"data":[{"id":"1234","login":"login","display_name":"DisplayName"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-client-id")
+	client.APIBaseURL = server.URL
+
+	user, err := client.GetUserByID("token", "")
+	if err != nil {
+		t.Fatalf("Unexpected error loading user: %s", err.Error())
+	}
+
+	if user.ID != "1234" || user.DisplayName != "DisplayName" {
+		t.Fatalf("Incorrect user returned from stand-in server: %+v", user)
+	}
+}
+
+func TestClientsWithDifferentClientIDs(t *testing.T) {
+	seen := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen <- r.Header.Get("Client-ID")
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	first := NewClient("first")
+	first.APIBaseURL = server.URL
+	second := NewClient("second")
+	second.APIBaseURL = server.URL
+
+	first.FindLiveStreams([]string{"1"})
+	second.FindLiveStreams([]string{"1"})
+
+	if id := <-seen; id != "first" {
+		t.Fatalf("Expected the first client ID, got %s", id)
+	}
+	if id := <-seen; id != "second" {
+		t.Fatalf("Expected the second client ID, got %s", id)
+	}
+}
@@ -0,0 +1,159 @@
+package twitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// ErrorKind is used to classify the errors returned from requests to the Twitch APIs so
+// callers can decide how to respond without inspecting status codes.
+type ErrorKind int
+
+// The different kinds of errors that can be returned from the Twitch client.
+const (
+	ErrUnknown ErrorKind = iota
+	ErrUnauthorized
+	ErrForbidden
+	ErrNotFound
+	ErrRateLimited
+	ErrUpstream
+	ErrDecode
+	ErrNotLive
+	ErrGeoBlocked
+	ErrSubscriberOnly
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrUnauthorized:
+		return "unauthorized"
+	case ErrForbidden:
+		return "forbidden"
+	case ErrNotFound:
+		return "not found"
+	case ErrRateLimited:
+		return "rate limited"
+	case ErrUpstream:
+		return "upstream error"
+	case ErrDecode:
+		return "decode failure"
+	case ErrNotLive:
+		return "not live"
+	case ErrGeoBlocked:
+		return "geo-blocked"
+	case ErrSubscriberOnly:
+		return "subscriber only"
+	}
+
+	return "unknown error"
+}
+
+// Error is the error type returned by all of the Client's requests to Twitch.
+type Error struct {
+	// Kind is the classification of this error.
+	Kind ErrorKind
+	// Op is a short description of the operation that failed (e.g. "get user").
+	Op string
+	// StatusCode is the HTTP status code returned by Twitch, or 0 if no response was received.
+	StatusCode int
+	// Message is the error message returned by Twitch, if there was one.
+	Message string
+	// Err is the underlying error that caused this one, if there was one.
+	Err error
+	// Reset is the time the rate limit will be reset for ErrRateLimited errors. This will
+	// be the zero time if Twitch did not provide it.
+	Reset time.Time
+	// MissingScopes are the OAuth scopes the user's token needs but was not granted.
+	MissingScopes []string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("twitch: %s: %s", e.Op, e.Kind)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (%d)", e.StatusCode)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+// KindOf will return the kind of the provided error. Errors that did not come from the
+// Twitch client are ErrUnknown. For a *BatchError, the kind of the first failed batch
+// is returned, and for a *TruncatedError the kind of the error that stopped pagination.
+func KindOf(err error) ErrorKind {
+	switch e := err.(type) {
+	case *Error:
+		return e.Kind
+	case *BatchError:
+		for i := 0; i < e.Total; i++ {
+			if batchErr, ok := e.Errors[i]; ok {
+				return KindOf(batchErr)
+			}
+		}
+	case *TruncatedError:
+		return KindOf(e.Err)
+	}
+
+	return ErrUnknown
+}
+
+// IsPartial will return true if the error was returned along with partial results, either a
+// *BatchError or a *TruncatedError.
+func IsPartial(err error) bool {
+	switch err.(type) {
+	case *BatchError, *TruncatedError:
+		return true
+	}
+
+	return false
+}
+
+// kindForStatus will classify an HTTP status code returned from Twitch.
+func kindForStatus(statusCode int) ErrorKind {
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case statusCode == http.StatusForbidden:
+		return ErrForbidden
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode >= 500:
+		return ErrUpstream
+	}
+
+	return ErrUnknown
+}
+
+// newStatusError will create an Error for a non-successful response. The Helix error
+// message will be read from the body if one is present.
+func newStatusError(op string, resp *http.Response) *Error {
+	helixError := struct {
+		Message string `json:"message"`
+	}{}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	json.Unmarshal(body, &helixError)
+
+	return &Error{
+		Kind:       kindForStatus(resp.StatusCode),
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Message:    helixError.Message,
+		Reset:      resetTime(resp),
+	}
+}
+
+// newDecodeError will wrap an error returned while decoding a response from Twitch.
+func newDecodeError(op string, err error) *Error {
+	return &Error{Kind: ErrDecode, Op: op, Err: err}
+}
@@ -0,0 +1,73 @@
+package twitch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientErrorKinds(t *testing.T) {
+	cases := []struct {
+		status   int
+		body     string
+		expected ErrorKind
+	}{
+		{http.StatusUnauthorized, `{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`, ErrUnauthorized},
+		{http.StatusForbidden, `{}`, ErrForbidden},
+		{http.StatusNotFound, `{}`, ErrNotFound},
+		{http.StatusTooManyRequests, `{}`, ErrRateLimited},
+		{http.StatusBadGateway, `{}`, ErrUpstream},
+		{http.StatusOK, `{not json`, ErrDecode},
+		{http.StatusOK, `{"data":[]}`, ErrNotFound},
+	}
+
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			w.Write([]byte(c.body))
+		}))
+
+		client := NewClient("test")
+		client.APIBaseURL = server.URL
+
+		user, err := client.GetUserByID("token", "1234")
+		server.Close()
+
+		if user != nil || KindOf(err) != c.expected {
+			t.Fatalf("Incorrect error for status %d and body %s. Expected=%s, Actual=%v",
+				c.status, c.body, c.expected, err)
+		}
+	}
+}
+
+func TestClientErrorIncludesHelixMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"Unauthorized","status":401,"message":"Invalid OAuth token"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	_, err := client.GetFollows(&User{ID: "1"})
+	twitchErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Expected a *twitch.Error, got: %v", err)
+	}
+
+	if twitchErr.StatusCode != 401 || twitchErr.Message != "Invalid OAuth token" {
+		t.Fatalf("Incorrect error details: %+v", twitchErr)
+	}
+}
+
+func TestKindOfUnknownErrors(t *testing.T) {
+	if KindOf(nil) != ErrUnknown {
+		t.Fatalf("Expected nil error to be unknown kind")
+	}
+
+	batchErr := &BatchError{Total: 2, Errors: map[int]error{1: &Error{Kind: ErrRateLimited}}}
+	if KindOf(batchErr) != ErrRateLimited {
+		t.Fatalf("Expected batch error kind to come from the failed batch")
+	}
+}
@@ -0,0 +1,107 @@
+package twitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// GetFollowedChannels will load every channel followed by the provided user. The user's
+// access token is required and must have the user:read:follows scope. If the later pages
+// were shed by the rate limiter, the channels loaded are returned with a *TruncatedError.
+func (c *Client) GetFollowedChannels(accessToken string, user *User) (*FollowedChannelsResponse, error) {
+
+	url := c.APIBaseURL + fmt.Sprintf(GetFollowedChannelsPathFormat, user.ID)
+	token, err := c.tokenFor(userToken, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	channelsJSON := &FollowedChannelsResponse{}
+	err = c.paginate("get followed channels", url, token, func(body io.Reader) (string, int, error) {
+		page := &FollowedChannelsResponse{}
+		err := json.NewDecoder(body).Decode(page)
+		if err != nil {
+			return "", 0, err
+		}
+
+		channelsJSON.Total = page.Total
+		channelsJSON.Data = append(channelsJSON.Data, page.Data...)
+		return page.Pagination.NextCursor(), len(page.Data), nil
+	})
+	if _, truncated := err.(*TruncatedError); err != nil && !truncated {
+		return nil, err
+	}
+
+	c.Logger.Debugf("Get followed channels response(%d of %d)", len(channelsJSON.Data), channelsJSON.Total)
+
+	return channelsJSON, err
+}
+
+// GetFollowedStreams will load the live streams for every channel followed by the provided
+// user. The user's access token is required and must have the user:read:follows scope. If
+// the later pages were shed by the rate limiter, the streams loaded are returned with a
+// *TruncatedError.
+func (c *Client) GetFollowedStreams(accessToken string, user *User) (*StreamsResponse, error) {
+
+	url := c.APIBaseURL + fmt.Sprintf(GetFollowedStreamsPathFormat, user.ID)
+	token, err := c.tokenFor(userToken, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	streamsJSON := &StreamsResponse{Data: make([]*Stream, 0)}
+	err = c.paginate("get followed streams", url, token, func(body io.Reader) (string, int, error) {
+		page := &StreamsResponse{}
+		err := json.NewDecoder(body).Decode(page)
+		if err != nil {
+			return "", 0, err
+		}
+
+		streamsJSON.Data = append(streamsJSON.Data, page.Data...)
+		return page.Pagination.NextCursor(), len(page.Data), nil
+	})
+	if _, truncated := err.(*TruncatedError); err != nil && !truncated {
+		return nil, err
+	}
+
+	c.Logger.Debugf("Get followed streams response(%d): %+v", len(streamsJSON.Data), streamsJSON.Data)
+
+	return streamsJSON, err
+}
+
+// GetLiveFollows will load the live streams for the channels followed by the provided user
+// using the streams/followed endpoint.
+//
+// If the client has LegacyFollowsFallback enabled and the user's token was not granted the
+// user:read:follows scope (e.g. the account was linked before the scope was requested), the
+// retired users/follows endpoint is used with FindLiveStreams instead.
+func (c *Client) GetLiveFollows(accessToken string, user *User) (*StreamsResponse, error) {
+
+	_, err := c.RequireToken(accessToken, ScopeUserReadFollows)
+	if KindOf(err) == ErrForbidden && c.LegacyFollowsFallback {
+		c.Logger.Warnf("Falling back to legacy follows lookup for user(%s): %s", user.ID, err.Error())
+		return c.getLegacyLiveFollows(user)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return c.GetFollowedStreams(accessToken, user)
+}
+
+// getLegacyLiveFollows will load the live follows for the user with the retired
+// users/follows endpoint. This is only kept for compatibility with older linked accounts.
+func (c *Client) getLegacyLiveFollows(user *User) (*StreamsResponse, error) {
+
+	follows, err := c.GetFollows(user)
+	if _, truncated := err.(*TruncatedError); err != nil && !truncated {
+		return nil, err
+	}
+
+	liveStreams, liveErr := c.FindLiveStreams(follows.FollowIDsList())
+	if liveErr != nil {
+		return liveStreams, liveErr
+	}
+
+	return liveStreams, err
+}
@@ -0,0 +1,108 @@
+package twitch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFollowedServer will serve token validation with the provided scopes along with the
+// followed streams and legacy follows endpoints.
+func newFollowedServer(t *testing.T, scopes string, paths *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		switch r.URL.Path {
+		case ValidateTokenPath:
+			w.Write([]byte(`{"login":"login","user_id":"1","scopes":[` + scopes + `],"expires_in":3600}`))
+		case "/streams/followed":
+			if r.Header.Get("Authorization") != "Bearer token" || r.URL.Query().Get("user_id") != "1" {
+				t.Errorf("Followed streams requested without the user's token: %s", r.URL.String())
+			}
+			w.Write([]byte(`{"data":[{"user_id":"2","user_login":"two","user_name":"Two","game_id":"27471",` +
+				`"game_name":"Minecraft","started_at":"2017-12-01T12:00:00Z"}]}`))
+		case "/users/follows":
+			w.Write([]byte(`{"total":1,"data":[{"from_id":"1","to_id":"3"}]}`))
+		case "/streams":
+			w.Write([]byte(`{"data":[{"user_id":"3"}]}`))
+		default:
+			t.Errorf("Unexpected request path: %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestGetLiveFollowsUsesFollowedStreams(t *testing.T) {
+	paths := []string{}
+	server := newFollowedServer(t, `"user:read:follows"`, &paths)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+	client.AuthBaseURL = server.URL
+	client.LegacyFollowsFallback = true
+
+	streams, err := client.GetLiveFollows("token", &User{ID: "1"})
+	if err != nil {
+		t.Fatalf("Unexpected error loading live follows: %s", err.Error())
+	}
+
+	if len(streams.Data) != 1 || streams.Data[0].UserLogin != "two" || len(paths) != 2 {
+		t.Fatalf("Incorrect live follows(%v): %+v", paths, streams.Data)
+	}
+
+	stream := streams.Data[0]
+	if stream.GameName != "Minecraft" || stream.StartedAt.IsZero() || stream.Uptime() <= 0 {
+		t.Fatalf("Expected the stream's game and start time: %+v", stream)
+	}
+}
+
+func TestGetLiveFollowsLegacyFallback(t *testing.T) {
+	paths := []string{}
+	server := newFollowedServer(t, ``, &paths)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+	client.AuthBaseURL = server.URL
+
+	_, err := client.GetLiveFollows("token", &User{ID: "1"})
+	if KindOf(err) != ErrForbidden {
+		t.Fatalf("Expected missing scope error without the fallback enabled, got: %v", err)
+	}
+
+	client.LegacyFollowsFallback = true
+	streams, err := client.GetLiveFollows("token", &User{ID: "1"})
+	if err != nil {
+		t.Fatalf("Unexpected error loading legacy live follows: %s", err.Error())
+	}
+
+	if len(streams.Data) != 1 || streams.Data[0].UserID != "3" {
+		t.Fatalf("Incorrect legacy live follows: %+v", streams.Data)
+	}
+}
+
+func TestGetFollowedChannels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/channels/followed" || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Incorrect followed channels request: %s", r.URL.String())
+		}
+		w.Write([]byte(`{"total":1,"data":[{"broadcaster_id":"2","broadcaster_login":"two","broadcaster_name":"Two"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	channels, err := client.GetFollowedChannels("token", &User{ID: "1"})
+	if err != nil {
+		t.Fatalf("Unexpected error loading followed channels: %s", err.Error())
+	}
+
+	if channels.Total != 1 || channels.Data[0].BroadcasterLogin != "two" {
+		t.Fatalf("Incorrect followed channels: %+v", channels)
+	}
+
+	_, err = client.GetFollowedChannels("", &User{ID: "1"})
+	if KindOf(err) != ErrUnauthorized {
+		t.Fatalf("Expected unauthorized error without a user token, got: %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package twitch
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGameCacheExpiration is how long the results of a game lookup are kept by a
+// GameCache created with NewGameCache. Games and their IDs rarely change.
+const DefaultGameCacheExpiration = 24 * time.Hour
+
+// DefaultTopStreams is the number of streams requested by GetTopStreams.
+const DefaultTopStreams = 20
+
+// GameCache stores the results of game lookups so the same spoken game name doesn't need to
+// be looked up every time the user asks for it.
+type GameCache struct {
+	// Expiration is how long a lookup result is kept.
+	Expiration time.Duration
+
+	mutex   sync.Mutex
+	results map[string]*gameCacheEntry
+	now     func() time.Time
+}
+
+// gameCacheEntry is the result of a single game lookup in a GameCache.
+type gameCacheEntry struct {
+	games     []*Game
+	expiresAt time.Time
+}
+
+// NewGameCache will create an empty GameCache with the default expiration.
+func NewGameCache() *GameCache {
+	return &GameCache{
+		Expiration: DefaultGameCacheExpiration,
+		results:    make(map[string]*gameCacheEntry),
+		now:        time.Now,
+	}
+}
+
+// get will return the cached result for the lookup, false is returned if there is no result
+// or the result has expired.
+func (cache *GameCache) get(key string) ([]*Game, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry, ok := cache.results[key]
+	if !ok {
+		return nil, false
+	} else if !cache.now().Before(entry.expiresAt) {
+		delete(cache.results, key)
+		return nil, false
+	}
+
+	return entry.games, true
+}
+
+func (cache *GameCache) set(key string, games []*Game) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.results[key] = &gameCacheEntry{games: games, expiresAt: cache.now().Add(cache.Expiration)}
+}
+
+// gameCacheKey is the key for a lookup in the GameCache. Lookups differing only by case or
+// surrounding spaces share the same key.
+func gameCacheKey(op, query string) string {
+	return op + ":" + strings.ToLower(strings.TrimSpace(query))
+}
+
+// lookupGames will return the cached result for the query if there is one, otherwise the
+// games are requested from the URL and cached.
+func (c *Client) lookupGames(op, query, gamesURL string) ([]*Game, error) {
+
+	key := gameCacheKey(op, query)
+	if c.Games != nil {
+		if games, ok := c.Games.get(key); ok {
+			return games, nil
+		}
+	}
+
+	token, err := c.tokenFor(appToken, "")
+	if err != nil {
+		return nil, err
+	}
+
+	gamesJSON := &GamesResponse{}
+	err = c.getJSON(op, gamesURL, token, gamesJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Debugf("%s(%s) response(%d): %+v", op, query, len(gamesJSON.Data), gamesJSON.Data)
+
+	if c.Games != nil {
+		c.Games.set(key, gamesJSON.Data)
+	}
+
+	return gamesJSON.Data, nil
+}
+
+// SearchCategories will search for games and other categories whose name matches the query.
+// Only the first page of results is requested since the best matches are returned first.
+// Results are cached in the client's GameCache.
+func (c *Client) SearchCategories(query string) ([]*Game, error) {
+	searchURL := c.APIBaseURL + fmt.Sprintf(SearchCategoriesPathFormat, url.QueryEscape(query), DefaultSearchResults)
+	return c.lookupGames("search categories", query, searchURL)
+}
+
+// GetGameByName will load the game with exactly the provided name. An ErrNotFound error is
+// returned if there isn't a game with that name. Results are cached in the client's GameCache.
+func (c *Client) GetGameByName(name string) (*Game, error) {
+
+	gamesURL := c.APIBaseURL + fmt.Sprintf(GetGamesByNamePathFormat, url.QueryEscape(name))
+	games, err := c.lookupGames("get games", name, gamesURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(games) == 0 {
+		return nil, &Error{Kind: ErrNotFound, Op: "get games", Message: "no game named " + name}
+	}
+
+	return games[0], nil
+}
+
+// StreamsFilter limits the live streams returned by GetTopStreams.
+type StreamsFilter struct {
+	// GameIDs limits the streams to the games or categories with these IDs.
+	GameIDs []string
+	// Language limits the streams to the language, as a two letter ISO 639-1 code.
+	Language string
+}
+
+// GetTopStreams will load the live streams with the most viewers that match the filter, the
+// most watched stream first. Only the first DefaultTopStreams streams are requested.
+func (c *Client) GetTopStreams(filter *StreamsFilter) (*StreamsResponse, error) {
+
+	streamsURL := c.APIBaseURL + fmt.Sprintf(GetTopStreamsPathFormat, DefaultTopStreams)
+	for _, gameID := range filter.GameIDs {
+		streamsURL += "&game_id=" + url.QueryEscape(gameID)
+	}
+	if filter.Language != "" {
+		streamsURL += "&language=" + url.QueryEscape(filter.Language)
+	}
+
+	token, err := c.tokenFor(appToken, "")
+	if err != nil {
+		return nil, err
+	}
+
+	streamsJSON := &StreamsResponse{}
+	err = c.getJSON("get top streams", streamsURL, token, streamsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Debugf("Get top streams response(%d): %+v", len(streamsJSON.Data), streamsJSON.Data)
+
+	return streamsJSON, nil
+}
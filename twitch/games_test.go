@@ -0,0 +1,96 @@
+package twitch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSearchCategoriesCached(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/search/categories" || r.URL.Query().Get("query") == "" {
+			t.Errorf("Incorrect search categories request: %s", r.URL.String())
+		}
+		w.Write([]byte(`{"data":[{"id":"27471","name":"Minecraft"},{"id":"1","name":"Minecraft Dungeons"}]}`))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+	client.Games.now = func() time.Time { return now }
+
+	for _, query := range []string{"minecraft", "Minecraft ", "minecraft"} {
+		games, err := client.SearchCategories(query)
+		if err != nil || len(games) != 2 || games[0].ID != "27471" || games[0].Name != "Minecraft" {
+			t.Fatalf("Incorrect categories(%v): %+v", err, games)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("Expected the same search to be cached, got %d requests", requests)
+	}
+
+	now = now.Add(DefaultGameCacheExpiration)
+	client.SearchCategories("minecraft")
+	if requests != 2 {
+		t.Fatalf("Expected an expired search to be requested again, got %d requests", requests)
+	}
+}
+
+func TestGetGameByName(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/games" {
+			t.Errorf("Incorrect games request: %s", r.URL.String())
+		}
+		if r.URL.Query().Get("name") == "Just Chatting" {
+			w.Write([]byte(`{"data":[{"id":"509658","name":"Just Chatting"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	game, err := client.GetGameByName("Just Chatting")
+	if err != nil || game.ID != "509658" {
+		t.Fatalf("Incorrect game(%v): %+v", err, game)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err = client.GetGameByName("Not A Game")
+		if KindOf(err) != ErrNotFound {
+			t.Fatalf("Expected not found error for an unknown game, got: %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("Expected unknown games to be cached too, got %d requests", requests)
+	}
+}
+
+func TestGetTopStreams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/streams" || query.Get("type") != "live" || query.Get("first") != "20" ||
+			len(query["game_id"]) != 2 || query.Get("language") != "en" {
+			t.Errorf("Incorrect top streams request: %s", r.URL.String())
+		}
+		w.Write([]byte(`{"data":[{"id":"1","user_id":"2","game_id":"27471","game_name":"Minecraft",` +
+			`"viewer_count":5000}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	streams, err := client.GetTopStreams(&StreamsFilter{GameIDs: []string{"27471", "1"}, Language: "en"})
+	if err != nil || len(streams.Data) != 1 || streams.Data[0].GameID != "27471" {
+		t.Fatalf("Incorrect top streams(%v): %+v", err, streams)
+	}
+}
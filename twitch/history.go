@@ -0,0 +1,184 @@
+package twitch
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultHistoryExpiration is how long a user's recent streams are kept after the last
+// stream was started.
+const DefaultHistoryExpiration = 24 * time.Hour
+
+// HistoryStore keeps the list of streams recently played by each user. The most recently
+// played stream is the user's current stream. Streams are identified by the streamer's
+// user ID and users by their Twitch user ID.
+type HistoryStore interface {
+	// Push will make the stream the user's current stream, removing any earlier
+	// occurrence of it from their history.
+	Push(userID, streamUserID string) error
+	// Current will return the user's current stream, or the empty string if there is none.
+	Current(userID string) (string, error)
+	// Pop will remove the user's current stream and return the new current stream (the
+	// previously played stream), or the empty string if the history is now empty.
+	Pop(userID string) (string, error)
+	// Previous will atomically remove the user's current stream, and any streams before
+	// it that aren't in the list of live streams, and return the new current stream. The
+	// empty string is returned if none of the previously played streams are live.
+	Previous(userID string, liveStreamUserIDs []string) (string, error)
+	// List will return the user's history, most recently played first.
+	List(userID string) ([]string, error)
+	// Clear will remove the user's entire history.
+	Clear(userID string) error
+}
+
+// memoryHistory is the history for a single user in a MemoryHistoryStore.
+type memoryHistory struct {
+	streams   []string
+	expiresAt time.Time
+}
+
+// MemoryHistoryStore is a HistoryStore that keeps every user's history in memory. It is
+// safe for concurrent use but the history is lost when the process exits.
+type MemoryHistoryStore struct {
+	// Expiration is how long a user's history is kept after the last push.
+	Expiration time.Duration
+
+	mutex     sync.Mutex
+	histories map[string]*memoryHistory
+	now       func() time.Time
+}
+
+// NewMemoryHistoryStore will create an empty MemoryHistoryStore.
+func NewMemoryHistoryStore() *MemoryHistoryStore {
+	return &MemoryHistoryStore{
+		Expiration: DefaultHistoryExpiration,
+		histories:  make(map[string]*memoryHistory),
+		now:        time.Now,
+	}
+}
+
+// history will return the user's history if it has not expired. The caller must hold the mutex.
+func (store *MemoryHistoryStore) history(userID string) *memoryHistory {
+	history, ok := store.histories[userID]
+	if !ok {
+		return nil
+	}
+
+	if !store.now().Before(history.expiresAt) {
+		delete(store.histories, userID)
+		return nil
+	}
+
+	return history
+}
+
+// Push will make the stream the user's current stream.
+func (store *MemoryHistoryStore) Push(userID, streamUserID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	history := store.history(userID)
+	if history == nil {
+		history = &memoryHistory{}
+		store.histories[userID] = history
+	}
+
+	streams := make([]string, 0, len(history.streams)+1)
+	streams = append(streams, streamUserID)
+	for _, uid := range history.streams {
+		if uid != streamUserID {
+			streams = append(streams, uid)
+		}
+	}
+
+	history.streams = streams
+	history.expiresAt = store.now().Add(store.Expiration)
+
+	return nil
+}
+
+// Current will return the user's current stream.
+func (store *MemoryHistoryStore) Current(userID string) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	history := store.history(userID)
+	if history == nil || len(history.streams) == 0 {
+		return "", nil
+	}
+
+	return history.streams[0], nil
+}
+
+// Pop will remove the user's current stream and return the new current stream.
+func (store *MemoryHistoryStore) Pop(userID string) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	history := store.history(userID)
+	if history == nil || len(history.streams) == 0 {
+		return "", nil
+	}
+
+	history.streams = history.streams[1:]
+	if len(history.streams) == 0 {
+		delete(store.histories, userID)
+		return "", nil
+	}
+
+	return history.streams[0], nil
+}
+
+// Previous will remove the user's current stream and any earlier streams that aren't live,
+// returning the new current stream.
+func (store *MemoryHistoryStore) Previous(userID string, liveStreamUserIDs []string) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	history := store.history(userID)
+	if history == nil || len(history.streams) == 0 {
+		return "", nil
+	}
+
+	live := make(map[string]bool, len(liveStreamUserIDs))
+	for _, uid := range liveStreamUserIDs {
+		live[uid] = true
+	}
+
+	for index, uid := range history.streams[1:] {
+		if live[uid] {
+			history.streams = history.streams[index+1:]
+			return uid, nil
+		}
+	}
+
+	delete(store.histories, userID)
+
+	return "", nil
+}
+
+// List will return the user's history, most recently played first.
+func (store *MemoryHistoryStore) List(userID string) ([]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	history := store.history(userID)
+	if history == nil {
+		return []string{}, nil
+	}
+
+	streams := make([]string, len(history.streams))
+	copy(streams, history.streams)
+
+	return streams, nil
+}
+
+// Clear will remove the user's entire history.
+func (store *MemoryHistoryStore) Clear(userID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.histories, userID)
+
+	return nil
+}
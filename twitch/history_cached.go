@@ -0,0 +1,108 @@
+package twitch
+
+import (
+	"github.com/kpango/glg"
+)
+
+// CachedHistoryStore is a HistoryStore that keeps the history in a persistent store with a
+// faster, possibly short lived, store in front of it (e.g. Redis in front of PostgreSQL).
+// Every change is written to both stores. The current stream is read from the cache and
+// falls back to the persistent store, which is the source of truth for everything else.
+type CachedHistoryStore struct {
+	Cache      HistoryStore
+	Persistent HistoryStore
+}
+
+// NewCachedHistoryStore will create a CachedHistoryStore with the cache in front of the
+// persistent store.
+func NewCachedHistoryStore(cache, persistent HistoryStore) *CachedHistoryStore {
+	return &CachedHistoryStore{
+		Cache:      cache,
+		Persistent: persistent,
+	}
+}
+
+// Push will make the stream the user's current stream in both stores. The cache is written
+// first so the stream that is playing can still be found if the persistent store is down,
+// a failure to update either store is only logged unless both of them fail.
+func (store *CachedHistoryStore) Push(userID, streamUserID string) error {
+	cacheErr := store.Cache.Push(userID, streamUserID)
+	if cacheErr != nil {
+		glg.Warnf("Failed to push recent stream to the history cache: %s", cacheErr.Error())
+	}
+
+	err := store.Persistent.Push(userID, streamUserID)
+	if err != nil {
+		if cacheErr != nil {
+			return err
+		}
+		glg.Warnf("Failed to push recent stream to the persistent history: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Current will return the user's current stream from the cache, or from the persistent
+// store if the cache doesn't have one.
+func (store *CachedHistoryStore) Current(userID string) (string, error) {
+	current, err := store.Cache.Current(userID)
+	if err != nil {
+		glg.Warnf("Failed to read current stream from the history cache: %s", err.Error())
+	} else if current != "" {
+		return current, nil
+	}
+
+	return store.Persistent.Current(userID)
+}
+
+// Pop will remove the user's current stream from both stores and return the new current
+// stream from the persistent store.
+func (store *CachedHistoryStore) Pop(userID string) (string, error) {
+	current, err := store.Persistent.Pop(userID)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = store.Cache.Pop(userID)
+	if err != nil {
+		glg.Warnf("Failed to pop recent stream from the history cache: %s", err.Error())
+	}
+
+	return current, nil
+}
+
+// Previous will move both stores back to the previous live stream and return the new
+// current stream from the persistent store.
+func (store *CachedHistoryStore) Previous(userID string, liveStreamUserIDs []string) (string, error) {
+	current, err := store.Persistent.Previous(userID, liveStreamUserIDs)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = store.Cache.Previous(userID, liveStreamUserIDs)
+	if err != nil {
+		glg.Warnf("Failed to move the history cache to the previous stream: %s", err.Error())
+	}
+
+	return current, nil
+}
+
+// List will return the user's history from the persistent store.
+func (store *CachedHistoryStore) List(userID string) ([]string, error) {
+	return store.Persistent.List(userID)
+}
+
+// Clear will remove the user's history from both stores.
+func (store *CachedHistoryStore) Clear(userID string) error {
+	err := store.Persistent.Clear(userID)
+	if err != nil {
+		return err
+	}
+
+	err = store.Cache.Clear(userID)
+	if err != nil {
+		glg.Warnf("Failed to clear the history cache: %s", err.Error())
+	}
+
+	return nil
+}
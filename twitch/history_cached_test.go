@@ -0,0 +1,84 @@
+package twitch
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCachedHistoryStore(t *testing.T) {
+	testHistoryStoreConformance(t, func() HistoryStore {
+		return NewCachedHistoryStore(NewMemoryHistoryStore(), NewMemoryHistoryStore())
+	})
+}
+
+func TestCachedHistoryStoreFallsBackWhenCacheExpires(t *testing.T) {
+	cache := NewMemoryHistoryStore()
+	history := NewCachedHistoryStore(cache, NewMemoryHistoryStore())
+
+	mockUser := createRandomMockUser()
+	mockStream1 := createRandomMockStream()
+	mockStream2 := createRandomMockStream()
+	SaveUsersCurrentStream(history, mockUser, mockStream1)
+	SaveUsersCurrentStream(history, mockUser, mockStream2)
+
+	now := time.Now().Add(DefaultHistoryExpiration + time.Minute)
+	cache.now = func() time.Time { return now }
+
+	current, err := history.Current(mockUser.ID)
+	if err != nil {
+		t.Fatalf("Error loading current stream: %s", err.Error())
+	}
+	if current != mockStream2.UserID {
+		t.Fatalf("Expected current stream %s from the persistent store, got %s", mockStream2.UserID, current)
+	}
+
+	previous, err := history.Pop(mockUser.ID)
+	if err != nil {
+		t.Fatalf("Error popping current stream: %s", err.Error())
+	}
+	if previous != mockStream1.UserID {
+		t.Fatalf("Expected previous stream %s from the persistent store, got %s", mockStream1.UserID, previous)
+	}
+}
+
+// failingPushHistoryStore is a HistoryStore that can't save new streams.
+type failingPushHistoryStore struct {
+	HistoryStore
+}
+
+func (failingPushHistoryStore) Push(userID, streamUserID string) error {
+	return errors.New("store unavailable")
+}
+
+func TestCachedHistoryStorePushFailures(t *testing.T) {
+	mockUser := createRandomMockUser()
+	mockStream := createRandomMockStream()
+
+	history := NewCachedHistoryStore(NewMemoryHistoryStore(), failingPushHistoryStore{NewMemoryHistoryStore()})
+	err := history.Push(mockUser.ID, mockStream.UserID)
+	if err != nil {
+		t.Fatalf("A persistent store failure should not fail the push: %s", err.Error())
+	}
+	current, err := history.Current(mockUser.ID)
+	if err != nil || current != mockStream.UserID {
+		t.Fatalf("Expected current stream %s from the cache, got %s (err=%v)", mockStream.UserID, current, err)
+	}
+
+	history = NewCachedHistoryStore(failingPushHistoryStore{NewMemoryHistoryStore()}, NewMemoryHistoryStore())
+	err = history.Push(mockUser.ID, mockStream.UserID)
+	if err != nil {
+		t.Fatalf("A cache failure should not fail the push: %s", err.Error())
+	}
+	current, err = history.Current(mockUser.ID)
+	if err != nil || current != mockStream.UserID {
+		t.Fatalf("Expected current stream %s from the persistent store, got %s (err=%v)", mockStream.UserID, current, err)
+	}
+
+	history = NewCachedHistoryStore(failingPushHistoryStore{NewMemoryHistoryStore()},
+		failingPushHistoryStore{NewMemoryHistoryStore()})
+	err = history.Push(mockUser.ID, mockStream.UserID)
+	if err == nil {
+		t.Fatal("Expected an error when neither store could save the stream")
+	}
+}
@@ -0,0 +1,161 @@
+package twitch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// RedisHistoryStore is a HistoryStore that keeps each user's history in a Redis list. The
+// list expires after the configured expiration, which is refreshed on every push.
+type RedisHistoryStore struct {
+	// Expiration is how long a user's history is kept after the last push.
+	Expiration time.Duration
+
+	pool *redis.Pool
+}
+
+// NewRedisHistoryStore will create a new RedisHistoryStore connected to the Redis server
+// at the provided URL.
+func NewRedisHistoryStore(redisURL string) *RedisHistoryStore {
+	return &RedisHistoryStore{
+		Expiration: DefaultHistoryExpiration,
+		pool:       newRedisPool(redisURL),
+	}
+}
+
+func newRedisPool(addr string) *redis.Pool {
+	// 25 is the maximum number of active connections for the Heroku Redis free tier
+	return &redis.Pool{
+		MaxIdle:     3,
+		MaxActive:   25,
+		IdleTimeout: 240 * time.Second,
+		Dial:        func() (redis.Conn, error) { return redis.DialURL(addr) },
+	}
+}
+
+// previousScript will pop the current stream off of the history list (KEYS[1]) and keep
+// popping until the new current stream is one of the live streams (ARGV). The new current
+// stream is returned, or nil if the list was emptied. Running this as a script makes the
+// whole operation atomic so concurrent requests for the same user can't interleave.
+var previousScript = redis.NewScript(1, `
+local live = {}
+for _, uid in ipairs(ARGV) do
+	live[uid] = true
+end
+
+redis.call("LPOP", KEYS[1])
+while true do
+	local uid = redis.call("LINDEX", KEYS[1], 0)
+	if not uid then
+		return false
+	end
+	if live[uid] then
+		return uid
+	end
+	redis.call("LPOP", KEYS[1])
+end
+`)
+
+// popScript will pop the current stream off of the history list (KEYS[1]) and return the
+// new current stream, or nil if the list was emptied. Like previousScript this runs
+// atomically so concurrent pops can't both see the same new current stream.
+var popScript = redis.NewScript(1, `
+redis.call("LPOP", KEYS[1])
+return redis.call("LINDEX", KEYS[1], 0)
+`)
+
+func historyListName(userID string) string {
+	return fmt.Sprintf("twitch_recent_streams:%s", userID)
+}
+
+// Push will make the stream the user's current stream. The list is set to automatically
+// expire, this expiration time will be updated on each push.
+func (store *RedisHistoryStore) Push(userID, streamUserID string) error {
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	listName := historyListName(userID)
+	conn.Send("MULTI")
+	// Remove previous occurrences of this stream UserID if they exist already in the list
+	conn.Send("LREM", listName, 0, streamUserID)
+	conn.Send("LPUSH", listName, streamUserID)
+	conn.Send("EXPIRE", listName, int(store.Expiration.Seconds()))
+	_, err := conn.Do("EXEC")
+
+	return err
+}
+
+// Current will return the user's current stream.
+func (store *RedisHistoryStore) Current(userID string) (string, error) {
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.String(conn.Do("LINDEX", historyListName(userID), 0))
+	if err == redis.ErrNil {
+		return "", nil
+	}
+
+	return reply, err
+}
+
+// Pop will remove the user's current stream and return the new current stream. This is
+// done in a single script on the Redis server.
+func (store *RedisHistoryStore) Pop(userID string) (string, error) {
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.String(popScript.Do(conn, historyListName(userID)))
+	if err == redis.ErrNil {
+		return "", nil
+	}
+
+	return reply, err
+}
+
+// Previous will remove the user's current stream and any earlier streams that aren't live,
+// returning the new current stream. This is done in a single script on the Redis server.
+func (store *RedisHistoryStore) Previous(userID string, liveStreamUserIDs []string) (string, error) {
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	keysAndArgs := make([]interface{}, 0, len(liveStreamUserIDs)+1)
+	keysAndArgs = append(keysAndArgs, historyListName(userID))
+	for _, uid := range liveStreamUserIDs {
+		keysAndArgs = append(keysAndArgs, uid)
+	}
+
+	reply, err := redis.String(previousScript.Do(conn, keysAndArgs...))
+	if err == redis.ErrNil {
+		return "", nil
+	}
+
+	return reply, err
+}
+
+// List will return the user's history, most recently played first.
+func (store *RedisHistoryStore) List(userID string) ([]string, error) {
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.Strings(conn.Do("LRANGE", historyListName(userID), 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		reply = []string{}
+	}
+
+	return reply, nil
+}
+
+// Clear will remove the user's entire history.
+func (store *RedisHistoryStore) Clear(userID string) error {
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", historyListName(userID))
+
+	return err
+}
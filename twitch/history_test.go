@@ -0,0 +1,375 @@
+package twitch
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// testRedisURL is the Redis server used for the Redis history store tests, these tests are
+// skipped if the server can't be reached.
+func testRedisURL() string {
+	if redisURL := os.Getenv("TEST_REDIS_URL"); redisURL != "" {
+		return redisURL
+	}
+
+	return "redis://127.0.0.1:6379"
+}
+
+func newTestRedisHistoryStore(t *testing.T) *RedisHistoryStore {
+	store := NewRedisHistoryStore(testRedisURL())
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("PING"); err != nil {
+		t.Skipf("Redis is not available at %s: %s", testRedisURL(), err.Error())
+	}
+
+	return store
+}
+
+func TestMemoryHistoryStore(t *testing.T) {
+	testHistoryStoreConformance(t, func() HistoryStore { return NewMemoryHistoryStore() })
+}
+
+func TestRedisHistoryStore(t *testing.T) {
+	store := newTestRedisHistoryStore(t)
+	testHistoryStoreConformance(t, func() HistoryStore { return store })
+}
+
+// testHistoryStoreConformance is the suite of tests every HistoryStore implementation needs
+// to pass. Every test uses new random users so the stores don't need to be emptied.
+func testHistoryStoreConformance(t *testing.T, newStore func() HistoryStore) {
+	tests := map[string]func(*testing.T, HistoryStore){
+		"Push":               testHistoryPush,
+		"List":               testHistoryList,
+		"Current":            testHistoryCurrent,
+		"Pop":                testHistoryPop,
+		"Previous":           testHistoryPrevious,
+		"Clear":              testHistoryClear,
+		"ConcurrentUse":      testHistoryConcurrentUse,
+		"ConcurrentPop":      testHistoryConcurrentPop,
+		"ConcurrentPrevious": testHistoryConcurrentPrevious,
+	}
+
+	for name, test := range tests {
+		store := newStore()
+		t.Run(name, func(t *testing.T) { test(t, store) })
+	}
+}
+
+func testHistoryPush(t *testing.T, history HistoryStore) {
+	mockUser1 := createRandomMockUser()
+	mockUser2 := createRandomMockUser()
+
+	mockStream1 := createRandomMockStream()
+	mockStream2 := createRandomMockStream()
+
+	SaveUsersCurrentStream(history, mockUser1, mockStream1)
+	if !validateListSize(t, history, mockUser1, 1) ||
+		!validateListSize(t, history, mockUser2, 0) {
+		t.Fatalf("Failed to save current stream")
+	}
+
+	SaveUsersCurrentStream(history, mockUser2, mockStream2)
+	if !validateListSize(t, history, mockUser2, 1) ||
+		!validateListSize(t, history, mockUser1, 1) {
+		t.Fatalf("Failed to save second recent stream")
+	}
+
+	SaveUsersCurrentStream(history, mockUser1, mockStream2)
+	if !validateListSize(t, history, mockUser1, 2) ||
+		!validateListSize(t, history, mockUser2, 1) {
+		t.Fatalf("Inserted recent stream into the wrong user's list")
+	}
+
+	if !validateListContents(t, history, mockUser1, []string{mockStream2.UserID, mockStream1.UserID}) ||
+		!validateListContents(t, history, mockUser2, []string{mockStream2.UserID}) {
+		t.Fatalf("The user's recent lists did not contain the correct stream user IDs")
+	}
+
+	// Pushing a stream that is already in the history should move it to the front
+	SaveUsersCurrentStream(history, mockUser1, mockStream1)
+	if !validateListContents(t, history, mockUser1, []string{mockStream1.UserID, mockStream2.UserID}) {
+		t.Fatalf("Pushing an existing stream did not move it to the front of the list")
+	}
+}
+
+func testHistoryList(t *testing.T, history HistoryStore) {
+	mockUser := createRandomMockUser()
+	mockStream1 := createRandomMockStream()
+	mockStream2 := createRandomMockStream()
+	mockStream3 := createRandomMockStream()
+
+	userIDs, err := history.List(mockUser.ID)
+	if err != nil || userIDs == nil || len(userIDs) != 0 {
+		t.Fatalf("Should have returned an empty slice when no active stream sessions, it did NOT")
+	}
+
+	SaveUsersCurrentStream(history, mockUser, mockStream1)
+	userIDs, _ = history.List(mockUser.ID)
+	if len(userIDs) != 1 || userIDs[0] != mockStream1.UserID {
+		t.Fatalf("List of streamer's user IDs is incorrect for recent streams with a single entry")
+	}
+
+	SaveUsersCurrentStream(history, mockUser, mockStream2)
+	userIDs, _ = history.List(mockUser.ID)
+	if len(userIDs) != 2 ||
+		userIDs[0] != mockStream2.UserID ||
+		userIDs[1] != mockStream1.UserID {
+		t.Fatalf("List of streamer's user IDs is incorrect for recent streams with a second entry")
+	}
+
+	SaveUsersCurrentStream(history, mockUser, mockStream3)
+	userIDs, _ = history.List(mockUser.ID)
+	if len(userIDs) != 3 ||
+		userIDs[0] != mockStream3.UserID ||
+		userIDs[1] != mockStream2.UserID ||
+		userIDs[2] != mockStream1.UserID {
+		t.Fatalf("List of streamer's user IDs is incorrect for recent streams with a third entry")
+	}
+}
+
+func testHistoryCurrent(t *testing.T, history HistoryStore) {
+	mockUser := createRandomMockUser()
+	mockStream1 := createRandomMockStream()
+	mockStream2 := createRandomMockStream()
+
+	streamerUserID, err := history.Current(mockUser.ID)
+	if err != nil || streamerUserID != "" {
+		t.Fatalf("Should have returned empty string when no active stream sessions, it did NOT")
+	}
+
+	SaveUsersCurrentStream(history, mockUser, mockStream1)
+	streamerUserID, _ = history.Current(mockUser.ID)
+	if streamerUserID != mockStream1.UserID {
+		t.Fatalf("Failed to retrieve new current stream after inserting one into list")
+	}
+
+	SaveUsersCurrentStream(history, mockUser, mockStream2)
+	streamerUserID, _ = history.Current(mockUser.ID)
+	if streamerUserID != mockStream2.UserID {
+		t.Fatalf("Failed to retrieve new current stream after inserting one into list")
+	}
+}
+
+func testHistoryPop(t *testing.T, history HistoryStore) {
+	mockUser := createRandomMockUser()
+	mockStream1 := createRandomMockStream()
+	mockStream2 := createRandomMockStream()
+	mockStream3 := createRandomMockStream()
+
+	nextUID, err := history.Pop(mockUser.ID)
+	if err != nil || nextUID != "" {
+		t.Fatalf("Error, removing a current stream should have returned empty string for the next UID but did not.")
+	}
+
+	SaveUsersCurrentStream(history, mockUser, mockStream1)
+	SaveUsersCurrentStream(history, mockUser, mockStream2)
+	SaveUsersCurrentStream(history, mockUser, mockStream3)
+
+	nextUID, _ = history.Pop(mockUser.ID)
+	if nextUID != mockStream2.UserID {
+		t.Fatalf("Error: incorrect next stream UID returned after removing current"+
+			" stream from list. Expected=%s, Actual=%s", mockStream2.UserID, nextUID)
+	}
+
+	nextUID, _ = history.Pop(mockUser.ID)
+	if nextUID != mockStream1.UserID {
+		t.Fatalf("Error: incorrect next stream UID returned after removing current"+
+			" stream from list. Expected=%s, Actual=%s", mockStream1.UserID, nextUID)
+	}
+
+	nextUID, _ = history.Pop(mockUser.ID)
+	if nextUID != "" {
+		t.Fatalf("Error: removed all current streams but still got a non-empty string for" +
+			" the next stream UID value.")
+	}
+}
+
+func testHistoryPrevious(t *testing.T, history HistoryStore) {
+	mockUser := createRandomMockUser()
+	mockStreams := []*Stream{createRandomMockStream(), createRandomMockStream(),
+		createRandomMockStream(), createRandomMockStream()}
+
+	previousUID, err := history.Previous(mockUser.ID, nil)
+	if err != nil || previousUID != "" {
+		t.Fatalf("Previous with no history should return the empty string: %s (err=%v)", previousUID, err)
+	}
+
+	for _, stream := range mockStreams {
+		SaveUsersCurrentStream(history, mockUser, stream)
+	}
+
+	// Only the first and second streams are live, so the third stream should be skipped
+	live := []string{mockStreams[0].UserID, mockStreams[1].UserID}
+	previousUID, err = history.Previous(mockUser.ID, live)
+	if err != nil || previousUID != mockStreams[1].UserID {
+		t.Fatalf("Expected previous live stream %s, got %s (err=%v)", mockStreams[1].UserID, previousUID, err)
+	}
+	if !validateListContents(t, history, mockUser, []string{mockStreams[1].UserID, mockStreams[0].UserID}) {
+		t.Fatalf("Previous should have removed the current stream and the stream that isn't live")
+	}
+
+	// Nothing previous is live, the whole history is used up
+	previousUID, err = history.Previous(mockUser.ID, []string{mockStreams[1].UserID})
+	if err != nil || previousUID != "" {
+		t.Fatalf("Expected no previous live stream, got %s (err=%v)", previousUID, err)
+	}
+	if !validateListSize(t, history, mockUser, 0) {
+		t.Fatalf("History should be empty when nothing previous is live")
+	}
+}
+
+func testHistoryClear(t *testing.T, history HistoryStore) {
+	mockUser1 := createRandomMockUser()
+	mockUser2 := createRandomMockUser()
+
+	SaveUsersCurrentStream(history, mockUser1, createRandomMockStream())
+	SaveUsersCurrentStream(history, mockUser2, createRandomMockStream())
+
+	if err := history.Clear(mockUser1.ID); err != nil {
+		t.Fatalf("Unexpected error clearing history: %s", err.Error())
+	}
+
+	if !validateListSize(t, history, mockUser1, 0) || !validateListSize(t, history, mockUser2, 1) {
+		t.Fatalf("Clearing a user's history did not remove only their streams")
+	}
+}
+
+func testHistoryConcurrentUse(t *testing.T, history HistoryStore) {
+	mockUser := createRandomMockUser()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			history.Push(mockUser.ID, fmt.Sprintf("stream%d", i%5))
+			history.Current(mockUser.ID)
+			history.List(mockUser.ID)
+		}(i)
+	}
+	wg.Wait()
+
+	if !validateListSize(t, history, mockUser, 5) {
+		t.Fatalf("Concurrent pushes did not result in one entry per stream")
+	}
+}
+
+func TestMemoryHistoryStoreExpires(t *testing.T) {
+	history := NewMemoryHistoryStore()
+	mockUser := createRandomMockUser()
+	SaveUsersCurrentStream(history, mockUser, createRandomMockStream())
+
+	now := time.Now().Add(DefaultHistoryExpiration + time.Minute)
+	history.now = func() time.Time { return now }
+
+	if !validateListSize(t, history, mockUser, 0) {
+		t.Fatalf("User's history should have expired")
+	}
+}
+
+func TestRedisHistoryStoreAppliesExpireTime(t *testing.T) {
+	history := newTestRedisHistoryStore(t)
+
+	mockUser := createRandomMockUser()
+	listName := historyListName(mockUser.ID)
+	SaveUsersCurrentStream(history, mockUser, createRandomMockStream())
+
+	conn := history.pool.Get()
+	defer conn.Close()
+	ttl, err := redis.Int(conn.Do("TTL", listName))
+	if err != nil {
+		t.Fatalf("Error trying to load TTL: %s", err.Error())
+	}
+
+	if ttl <= 0 {
+		t.Fatalf("Bad return value from TTL command on recent stream list: %d\n", ttl)
+	}
+}
+
+func testHistoryConcurrentPop(t *testing.T, history HistoryStore) {
+	mockUser := createRandomMockUser()
+
+	streams := 21
+	for i := 0; i < streams; i++ {
+		history.Push(mockUser.ID, fmt.Sprintf("stream%d", i))
+	}
+
+	// Every pop should remove exactly one stream, so no two pops can return the same new
+	// current stream.
+	results := make(chan string, streams)
+	var wg sync.WaitGroup
+	for i := 0; i < streams-1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nextUID, err := history.Pop(mockUser.ID)
+			if err != nil {
+				t.Errorf("Unexpected error popping the current stream: %s", err.Error())
+			}
+			results <- nextUID
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	seen := make(map[string]bool)
+	for nextUID := range results {
+		if nextUID == "" || seen[nextUID] {
+			t.Fatalf("Concurrent pops returned a duplicate or empty stream: %q", nextUID)
+		}
+		seen[nextUID] = true
+	}
+
+	if !validateListContents(t, history, mockUser, []string{"stream0"}) {
+		t.Fatalf("Concurrent pops should leave only the oldest stream")
+	}
+}
+
+func testHistoryConcurrentPrevious(t *testing.T, history HistoryStore) {
+	mockUser := createRandomMockUser()
+
+	streams := 21
+	live := make([]string, 0, streams)
+	for i := 0; i < streams; i++ {
+		uid := fmt.Sprintf("stream%d", i)
+		history.Push(mockUser.ID, uid)
+		live = append(live, uid)
+	}
+
+	// Every call should move back exactly one stream, so each one has to see a different
+	// current stream and no two calls can return the same previous stream.
+	results := make(chan string, streams)
+	var wg sync.WaitGroup
+	for i := 0; i < streams-1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			previousUID, err := history.Previous(mockUser.ID, live)
+			if err != nil {
+				t.Errorf("Unexpected error moving to the previous stream: %s", err.Error())
+			}
+			results <- previousUID
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	seen := make(map[string]bool)
+	for previousUID := range results {
+		if previousUID == "" || seen[previousUID] {
+			t.Fatalf("Concurrent previous calls returned a duplicate or empty stream: %q", previousUID)
+		}
+		seen[previousUID] = true
+	}
+
+	if !validateListContents(t, history, mockUser, []string{"stream0"}) {
+		t.Fatalf("Concurrent previous calls should leave only the oldest stream")
+	}
+}
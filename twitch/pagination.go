@@ -0,0 +1,105 @@
+package twitch
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+// PageSize is the number of entries requested for each page of a Helix list endpoint. This
+// is the maximum value Twitch allows for the first query parameter.
+const PageSize = 100
+
+// DefaultMaxPages is the upper bound on the number of pages that will be requested when
+// walking a paginated list endpoint, unless the Client is configured otherwise.
+const DefaultMaxPages = 10
+
+// TruncatedError is returned when walking a paginated list endpoint stopped before the last
+// page because the remaining pages were shed by the rate limiter. The entries from the pages
+// that were loaded are still returned with this error.
+type TruncatedError struct {
+	// Pages is the number of pages that were loaded.
+	Pages int
+	// Err is the error for the first page that was not loaded.
+	Err error
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("results truncated after %d pages: %s", e.Pages, e.Err.Error())
+}
+
+// pageHandler is responsible for decoding a single page of a list response. The cursor for
+// the next page should be returned along with the number of entries found on this page.
+type pageHandler func(body io.Reader) (cursor string, count int, err error)
+
+// paginate will walk every page of the Helix list endpoint at the provided URL, passing
+// each page to the handler. Pagination stops when there is no cursor for the next page,
+// a page is empty, or the client's MaxPages limit is reached. Errors returned from the
+// handler are reported as decode failures. If the rate limiter sheds one of the later pages
+// a *TruncatedError is returned, the pages already passed to the handler are still valid.
+func (c *Client) paginate(op, listURL, accessToken string, handler pageHandler) error {
+
+	maxPages := c.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+
+	cursor := ""
+	for page := 0; page < maxPages; page++ {
+		pageURL, err := pageURL(listURL, cursor)
+		if err != nil {
+			return &Error{Kind: ErrUnknown, Op: op, Err: err}
+		}
+
+		c.Logger.Debugf("Requesting page %d with url: %s", page, pageURL)
+		req, err := c.newRequest(pageURL, accessToken)
+		if err != nil {
+			return &Error{Kind: ErrUnknown, Op: op, Err: err}
+		}
+
+		// Only the first page is critical, if the rate limit is nearly exhausted the
+		// remaining pages are skipped and the results found so far are reported as truncated.
+		resp, err := c.doWithPriority(op, req, page == 0)
+		if err != nil {
+			if page > 0 && KindOf(err) == ErrRateLimited {
+				c.Logger.Warnf("Stopped paginating after %d pages: %s", page, err.Error())
+				return &TruncatedError{Pages: page, Err: err}
+			}
+			return err
+		}
+
+		next, count, err := handler(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			c.Logger.Errorf("Failed to decode Twitch %s JSON: %s", op, err.Error())
+			return newDecodeError(op, err)
+		}
+
+		if next == "" || count == 0 || next == cursor {
+			return nil
+		}
+		cursor = next
+	}
+
+	c.Logger.Warnf("Stopped paginating after reaching the limit of %d pages: %s", maxPages, listURL)
+	return nil
+}
+
+// pageURL will add the page size and, if provided, the cursor for the requested page to
+// the query string of the list URL.
+func pageURL(listURL, cursor string) (string, error) {
+	u, err := url.Parse(listURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	query.Set("first", strconv.Itoa(PageSize))
+	if cursor != "" {
+		query.Set("after", cursor)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,107 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newPagedFollowsServer will create a server that returns the requested number of follows
+// pages, each with a single follow and a cursor pointing to the next page.
+func newPagedFollowsServer(t *testing.T, pages int, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Query().Get("first") != "100" {
+			t.Errorf("Expected page size of 100, got: %s", r.URL.Query().Get("first"))
+		}
+
+		page := 0
+		fmt.Sscanf(r.URL.Query().Get("after"), "page%d", &page)
+
+		cursor := ""
+		if page < pages-1 {
+			cursor = fmt.Sprintf("page%d", page+1)
+		}
+		fmt.Fprintf(w, `{"total":%d,"data":[{"from_id":"1","to_id":"%d"}],"pagination":{"cursor":"%s"}}`,
+			pages, page, cursor)
+	}))
+}
+
+func TestGetFollowsWalksAllPages(t *testing.T) {
+	requests := 0
+	server := newPagedFollowsServer(t, 3, &requests)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	follows, err := client.GetFollows(&User{ID: "1"})
+	if err != nil {
+		t.Fatalf("Unexpected error loading follows: %s", err.Error())
+	}
+
+	ids := follows.FollowIDsList()
+	if requests != 3 || len(ids) != 3 || ids[0] != "0" || ids[1] != "1" || ids[2] != "2" {
+		t.Fatalf("Incorrect follows after paginating (requests=%d): %v", requests, ids)
+	}
+}
+
+func TestGetFollowsRespectsMaxPages(t *testing.T) {
+	requests := 0
+	server := newPagedFollowsServer(t, 5, &requests)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+	client.MaxPages = 2
+
+	follows, err := client.GetFollows(&User{ID: "1"})
+	if err != nil {
+		t.Fatalf("Unexpected error loading follows: %s", err.Error())
+	}
+
+	if requests != 2 || len(follows.Data) != 2 {
+		t.Fatalf("Expected 2 pages to be loaded, requests=%d follows=%d", requests, len(follows.Data))
+	}
+}
+
+func TestGetFollowsReportsTruncatedPages(t *testing.T) {
+	requests := 0
+	pages := newPagedFollowsServer(t, 3, &requests)
+	defer pages.Close()
+
+	// Report the rate limit as nearly exhausted so the later pages are shed
+	reset := time.Now().Add(time.Minute).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RateLimitRemainingHeader, fmt.Sprintf("%d", DefaultRateLimitReserve))
+		w.Header().Set(RateLimitResetHeader, fmt.Sprintf("%d", reset))
+		pages.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	follows, err := client.GetFollows(&User{ID: "1"})
+	truncated, ok := err.(*TruncatedError)
+	if !ok || truncated.Pages != 1 || KindOf(err) != ErrRateLimited {
+		t.Fatalf("Expected a truncated error after the first page, got: %v", err)
+	}
+	if requests != 1 || follows == nil || len(follows.Data) != 1 {
+		t.Fatalf("Expected the first page to be returned, requests=%d follows=%+v", requests, follows)
+	}
+}
+
+func TestPageURL(t *testing.T) {
+	result, err := pageURL("https://example.com/streams?type=live&user_id=1&user_id=2", "abc")
+	if err != nil {
+		t.Fatalf("Unexpected error building page URL: %s", err.Error())
+	}
+
+	expected := "https://example.com/streams?after=abc&first=100&type=live&user_id=1&user_id=2"
+	if result != expected {
+		t.Fatalf("Incorrect page URL. Expected=%s, Actual=%s", expected, result)
+	}
+}
@@ -0,0 +1,204 @@
+package twitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// The defaults used by a GQLPlaybackTokenProvider created with NewGQLPlaybackTokenProvider.
+// Playback access tokens are only available from the GQL API used by the Twitch website, so
+// the website's public client ID needs to be used for these requests.
+const (
+	DefaultGQLURL      = "https://gql.twitch.tv/gql"
+	DefaultGQLClientID = "kimne78kx3ncx6brgo4mv6wki5h1ko"
+)
+
+// playbackAccessTokenQuery is the GQL query used to load a playback access token for either
+// a live stream or a VOD.
+const playbackAccessTokenQuery = `query PlaybackAccessToken($login: String!, $isLive: Boolean!, $vodID: ID!, $isVod: Boolean!, $playerType: String!) {
+  streamPlaybackAccessToken(channelName: $login, params: {platform: "web", playerBackend: "mediaplayer", playerType: $playerType}) @include(if: $isLive) {
+    value
+    signature
+  }
+  videoPlaybackAccessToken(id: $vodID, params: {platform: "web", playerBackend: "mediaplayer", playerType: $playerType}) @include(if: $isVod) {
+    value
+    signature
+  }
+}`
+
+// PlaybackAccessToken is the token and signature that need to be sent to usher when
+// requesting the HLS master playlist for a live stream or VOD.
+type PlaybackAccessToken struct {
+	Value     string `json:"value"`
+	Signature string `json:"signature"`
+}
+
+// PlaybackTokenProvider is responsible for loading the playback access tokens used to
+// request stream playlists.
+type PlaybackTokenProvider interface {
+	// LiveToken will load the playback token for the live stream on the channel.
+	LiveToken(channelLogin string) (*PlaybackAccessToken, error)
+	// VODToken will load the playback token for the VOD with the provided ID.
+	VODToken(vodID string) (*PlaybackAccessToken, error)
+}
+
+// GQLPlaybackTokenProvider loads playback access tokens with the GQL PlaybackAccessToken query.
+type GQLPlaybackTokenProvider struct {
+	// URL is the GQL endpoint.
+	URL string
+	// ClientID is the client ID sent with GQL requests.
+	ClientID string
+	// HTTPClient is the transport used for GQL requests.
+	HTTPClient *http.Client
+}
+
+// NewGQLPlaybackTokenProvider will create a new provider using the production GQL endpoint.
+func NewGQLPlaybackTokenProvider(httpClient *http.Client) *GQLPlaybackTokenProvider {
+	return &GQLPlaybackTokenProvider{
+		URL:        DefaultGQLURL,
+		ClientID:   DefaultGQLClientID,
+		HTTPClient: httpClient,
+	}
+}
+
+// gqlRequest is the body of a GQL request.
+type gqlRequest struct {
+	OperationName string                 `json:"operationName"`
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// playbackAccessTokenResponse is the response to the PlaybackAccessToken query.
+type playbackAccessTokenResponse struct {
+	Data struct {
+		StreamPlaybackAccessToken *PlaybackAccessToken `json:"streamPlaybackAccessToken"`
+		VideoPlaybackAccessToken  *PlaybackAccessToken `json:"videoPlaybackAccessToken"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// LiveToken will load the playback token for the live stream on the channel.
+func (p *GQLPlaybackTokenProvider) LiveToken(channelLogin string) (*PlaybackAccessToken, error) {
+	result, err := p.query("get live playback token", map[string]interface{}{
+		"isLive": true, "login": strings.ToLower(channelLogin), "isVod": false, "vodID": "",
+		"playerType": "site",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return checkPlaybackToken("get live playback token", result.Data.StreamPlaybackAccessToken)
+}
+
+// VODToken will load the playback token for the VOD with the provided ID.
+func (p *GQLPlaybackTokenProvider) VODToken(vodID string) (*PlaybackAccessToken, error) {
+	result, err := p.query("get VOD playback token", map[string]interface{}{
+		"isLive": false, "login": "", "isVod": true, "vodID": vodID, "playerType": "site",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return checkPlaybackToken("get VOD playback token", result.Data.VideoPlaybackAccessToken)
+}
+
+func (p *GQLPlaybackTokenProvider) query(op string, variables map[string]interface{}) (*playbackAccessTokenResponse, error) {
+	body, err := json.Marshal(&gqlRequest{
+		OperationName: "PlaybackAccessToken",
+		Query:         playbackAccessTokenQuery,
+		Variables:     variables,
+	})
+	if err != nil {
+		return nil, &Error{Kind: ErrUnknown, Op: op, Err: err}
+	}
+
+	req, err := http.NewRequest("POST", p.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, &Error{Kind: ErrUnknown, Op: op, Err: err}
+	}
+	req.Header.Set("Client-ID", p.ClientID)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, &Error{Kind: ErrUpstream, Op: op, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newStatusError(op, resp)
+	}
+
+	result := &playbackAccessTokenResponse{}
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return nil, newDecodeError(op, err)
+	}
+
+	if len(result.Errors) > 0 {
+		return nil, &Error{Kind: ErrUpstream, Op: op, Message: result.Errors[0].Message}
+	}
+
+	return result, nil
+}
+
+// checkPlaybackToken will make sure a token was returned and that the token does not say
+// playback is forbidden (e.g. the content is geo-blocked).
+func checkPlaybackToken(op string, token *PlaybackAccessToken) (*PlaybackAccessToken, error) {
+	if token == nil || token.Value == "" {
+		return nil, &Error{Kind: ErrNotFound, Op: op, Message: "no playback token returned"}
+	}
+
+	value := struct {
+		Authorization struct {
+			Forbidden bool   `json:"forbidden"`
+			Reason    string `json:"reason"`
+		} `json:"authorization"`
+	}{}
+	json.Unmarshal([]byte(token.Value), &value)
+
+	if value.Authorization.Forbidden {
+		kind := ErrForbidden
+		if strings.Contains(strings.ToUpper(value.Authorization.Reason), "GEO") {
+			kind = ErrGeoBlocked
+		}
+		return nil, &Error{Kind: kind, Op: op, Message: value.Authorization.Reason}
+	}
+
+	return token, nil
+}
+
+// newUsherError will classify an error response from usher when requesting a playlist. The
+// body of a usher error is a list of errors with codes describing why playback was refused.
+func newUsherError(op string, resp *http.Response, live bool) *Error {
+	usherErrors := []struct {
+		Error     string `json:"error"`
+		ErrorCode string `json:"error_code"`
+	}{}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	json.Unmarshal(body, &usherErrors)
+
+	twitchErr := &Error{Kind: kindForStatus(resp.StatusCode), Op: op, StatusCode: resp.StatusCode}
+	if len(usherErrors) > 0 {
+		twitchErr.Message = usherErrors[0].Error
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && live:
+		// Usher doesn't have a playlist for channels that aren't live
+		twitchErr.Kind = ErrNotLive
+	case len(usherErrors) > 0 && usherErrors[0].ErrorCode == "content_geoblocked":
+		twitchErr.Kind = ErrGeoBlocked
+	case len(usherErrors) > 0 && (usherErrors[0].ErrorCode == "unauthorized_entitlements" ||
+		usherErrors[0].ErrorCode == "vod_manifest_restricted"):
+		twitchErr.Kind = ErrSubscriberOnly
+	}
+
+	return twitchErr
+}
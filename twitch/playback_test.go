@@ -0,0 +1,166 @@
+package twitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testMasterPlaylist is a master playlist in the format returned by usher.
+const testMasterPlaylist = `#EXTM3U
+#EXT-X-TWITCH-INFO:NODE="video-edge",SERVER-TIME="1512000000.00"
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="chunked",NAME="1080p60 (source)",AUTOSELECT=YES,DEFAULT=YES
+#EXT-X-STREAM-INF:PROGRAM-ID=1,BANDWIDTH=6000000,RESOLUTION=1920x1080,CODECS="avc1.64002A,mp4a.40.2",VIDEO="chunked"
+http://localhost/chunked.m3u8
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="720p60",NAME="720p60",AUTOSELECT=YES,DEFAULT=YES
+#EXT-X-STREAM-INF:PROGRAM-ID=1,BANDWIDTH=3000000,RESOLUTION=1280x720,CODECS="avc1.4D401F,mp4a.40.2",VIDEO="720p60"
+http://localhost/720p60.m3u8
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="720p30",NAME="720p",AUTOSELECT=YES,DEFAULT=YES
+#EXT-X-STREAM-INF:PROGRAM-ID=1,BANDWIDTH=2000000,RESOLUTION=1280x720,CODECS="avc1.4D401F,mp4a.40.2",VIDEO="720p30"
+http://localhost/720p30.m3u8
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="160p30",NAME="160p",AUTOSELECT=YES,DEFAULT=YES
+#EXT-X-STREAM-INF:PROGRAM-ID=1,BANDWIDTH=230000,RESOLUTION=284x160,CODECS="avc1.4D400C,mp4a.40.2",VIDEO="160p30"
+http://localhost/160p30.m3u8
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="audio_only",NAME="audio_only",AUTOSELECT=NO,DEFAULT=NO
+#EXT-X-STREAM-INF:PROGRAM-ID=1,BANDWIDTH=160000,CODECS="mp4a.40.2",VIDEO="audio_only"
+http://localhost/audio_only.m3u8
+`
+
+// fakePlaybackTokenProvider is a PlaybackTokenProvider that returns a fixed token, or the
+// provided error, without making any requests.
+type fakePlaybackTokenProvider struct {
+	token *PlaybackAccessToken
+	err   error
+}
+
+func (f *fakePlaybackTokenProvider) LiveToken(channelLogin string) (*PlaybackAccessToken, error) {
+	return f.token, f.err
+}
+
+func (f *fakePlaybackTokenProvider) VODToken(vodID string) (*PlaybackAccessToken, error) {
+	return f.token, f.err
+}
+
+// newUsherServer will serve the test master playlist for the channel "live" and VOD "123"
+// and respond with the provided status and body for anything else.
+func newUsherServer(t *testing.T, status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/channel/hls/live.m3u8" || r.URL.Path == "/vod/123.m3u8" {
+			if r.URL.Query().Get("token") != `{"channel":"live"}` && r.URL.Query().Get("nauth") != `{"channel":"live"}` {
+				t.Errorf("Playback token was not sent to usher: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(testMasterPlaylist))
+			return
+		}
+
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func newTestStreamClient(usherURL string, tokens PlaybackTokenProvider) *Client {
+	client := NewClient("test")
+	client.UsherBaseURL = usherURL
+	client.PlaybackTokens = tokens
+
+	return client
+}
+
+func TestGetStreamWithFakeTokenProvider(t *testing.T) {
+	server := newUsherServer(t, http.StatusNotFound, `[]`)
+	defer server.Close()
+
+	client := newTestStreamClient(server.URL, &fakePlaybackTokenProvider{
+		token: &PlaybackAccessToken{Value: `{"channel":"live"}`, Signature: "sig"},
+	})
+
+	selection, err := client.GetStream("Live", VariantConstraints{AudioOnly: true})
+	if err != nil {
+		t.Fatalf("Unexpected error loading stream: %s", err.Error())
+	}
+	if selection.Selected.Name != "audio_only" {
+		t.Fatalf("Incorrect variant selected: %s", selection.Selected.Name)
+	}
+
+	selection, err = client.GetVODStream("123", VariantConstraints{MaxHeight: 720, PreferHighFrameRate: true})
+	if err != nil {
+		t.Fatalf("Unexpected error loading VOD stream: %s", err.Error())
+	}
+	if selection.Selected.Name != "720p60" {
+		t.Fatalf("Incorrect VOD variant selected: %s", selection.Selected.Name)
+	}
+}
+
+func TestGetStreamErrors(t *testing.T) {
+	cases := []struct {
+		status   int
+		body     string
+		expected ErrorKind
+	}{
+		{http.StatusNotFound, `[{"error":"transcode does not exist","error_code":"transcode_does_not_exist"}]`, ErrNotLive},
+		{http.StatusForbidden, `[{"error":"Content is geoblocked","error_code":"content_geoblocked"}]`, ErrGeoBlocked},
+		{http.StatusForbidden, `[{"error":"No subscription","error_code":"unauthorized_entitlements"}]`, ErrSubscriberOnly},
+		{http.StatusForbidden, `[]`, ErrForbidden},
+	}
+
+	for _, c := range cases {
+		server := newUsherServer(t, c.status, c.body)
+		client := newTestStreamClient(server.URL, &fakePlaybackTokenProvider{
+			token: &PlaybackAccessToken{Value: `{"channel":"offline"}`, Signature: "sig"},
+		})
+
+		_, err := client.GetStream("offline", VariantConstraints{AudioOnly: true})
+		server.Close()
+		if KindOf(err) != c.expected {
+			t.Fatalf("Incorrect error for usher response %s. Expected=%s, Actual=%v", c.body, c.expected, err)
+		}
+	}
+
+	client := newTestStreamClient("http://localhost", &fakePlaybackTokenProvider{
+		err: &Error{Kind: ErrNotFound, Op: "get live playback token"},
+	})
+	_, err := client.GetStream("missing", VariantConstraints{AudioOnly: true})
+	if KindOf(err) != ErrNotFound {
+		t.Fatalf("Expected the token provider error to be returned, got: %v", err)
+	}
+}
+
+func TestGQLPlaybackTokenProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &gqlRequest{}
+		json.NewDecoder(r.Body).Decode(request)
+		if r.Method != "POST" || request.OperationName != "PlaybackAccessToken" ||
+			r.Header.Get("Client-ID") != DefaultGQLClientID {
+			t.Errorf("Invalid GQL request: %+v", request)
+		}
+
+		switch request.Variables["login"] {
+		case "live":
+			w.Write([]byte(`{"data":{"streamPlaybackAccessToken":{"value":"{\"authorization\":{\"forbidden\":false}}","signature":"sig"}}}`))
+		case "blocked":
+			w.Write([]byte(`{"data":{"streamPlaybackAccessToken":{"value":"{\"authorization\":{\"forbidden\":true,\"reason\":\"GEOBLOCKED\"}}","signature":"sig"}}}`))
+		default:
+			w.Write([]byte(`{"data":{"streamPlaybackAccessToken":null}}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewGQLPlaybackTokenProvider(http.DefaultClient)
+	provider.URL = server.URL
+
+	token, err := provider.LiveToken("Live")
+	if err != nil || token.Signature != "sig" {
+		t.Fatalf("Incorrect live token(%+v): %v", token, err)
+	}
+
+	_, err = provider.LiveToken("blocked")
+	if KindOf(err) != ErrGeoBlocked {
+		t.Fatalf("Expected geo-blocked error, got: %v", err)
+	}
+
+	_, err = provider.LiveToken("missing")
+	if KindOf(err) != ErrNotFound {
+		t.Fatalf("Expected not found error for a missing channel, got: %v", err)
+	}
+}
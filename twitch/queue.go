@@ -0,0 +1,345 @@
+package twitch
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxQueueHistory is the number of previously played streams kept in a PlaybackQueue.
+const MaxQueueHistory = 50
+
+// PlaybackQueue is the order a user moves through the live streams. The items are a
+// snapshot of the live streamers' user IDs that is only changed when the live streams
+// are refreshed, so next and previous behave the same between requests even when Twitch
+// returns the live streams in a different order.
+type PlaybackQueue struct {
+	// Items are the user IDs of the streamers in the queue.
+	Items []string `json:"items"`
+	// Cursor is the index of the current stream in Items, or -1 if nothing is playing.
+	Cursor int `json:"cursor"`
+	// History is the stack of previously played streamers, most recent last.
+	History []string `json:"history"`
+	// Source is where the live streams in the queue come from, nil for the user's followed
+	// channels.
+	Source *QueueSource `json:"source,omitempty"`
+	// GamePlays is the number of streams the user has played in each game, by game ID. Like
+	// the rest of the queue, the counts are kept until the queue expires.
+	GamePlays map[string]int `json:"game_plays,omitempty"`
+}
+
+// QueueSource is a list of live streams, other than the user's followed channels, that a
+// PlaybackQueue can move through.
+type QueueSource struct {
+	// GameID limits the queue to the live streams in the game or category.
+	GameID string `json:"game_id,omitempty"`
+	// GameName is the name of the game or category.
+	GameName string `json:"game_name,omitempty"`
+	// Browse moves through the most watched live streams on Twitch instead of a single game.
+	Browse bool `json:"browse,omitempty"`
+	// Language limits the browsed streams to the language, as a two letter ISO 639-1 code.
+	Language string `json:"language,omitempty"`
+	// GameIDs limits the browsed streams to these games, e.g. the games the user plays often.
+	GameIDs []string `json:"game_ids,omitempty"`
+}
+
+// NewPlaybackQueue will create an empty PlaybackQueue.
+func NewPlaybackQueue() *PlaybackQueue {
+	return &PlaybackQueue{
+		Items:   []string{},
+		Cursor:  -1,
+		History: []string{},
+	}
+}
+
+// Current will return the user ID of the current streamer, or the empty string if nothing
+// is playing.
+func (q *PlaybackQueue) Current() string {
+	if q.Cursor < 0 || q.Cursor >= len(q.Items) {
+		return ""
+	}
+
+	return q.Items[q.Cursor]
+}
+
+// Refresh will update the queue with the streamers that are live now. Streamers that are
+// still live keep their place, streamers that went offline are removed and newly live
+// streamers are added to the end of the queue. If the current streamer went offline the
+// cursor is moved to the streamer that would have been next. The return value is whether
+// the current streamer is still live.
+func (q *PlaybackQueue) Refresh(liveStreamUserIDs []string) bool {
+	current := q.Current()
+	live := make(map[string]bool, len(liveStreamUserIDs))
+	for _, uid := range liveStreamUserIDs {
+		live[uid] = true
+	}
+
+	items := make([]string, 0, len(liveStreamUserIDs))
+	queued := make(map[string]bool, len(liveStreamUserIDs))
+	cursor := -1
+	for index, uid := range q.Items {
+		if !live[uid] {
+			continue
+		}
+		if index == q.Cursor {
+			cursor = len(items)
+		} else if cursor == -1 && current != "" && index > q.Cursor {
+			// The current streamer went offline, this is the one that would have been next
+			cursor = len(items)
+		}
+		items = append(items, uid)
+		queued[uid] = true
+	}
+	for _, uid := range liveStreamUserIDs {
+		if !queued[uid] {
+			items = append(items, uid)
+			queued[uid] = true
+		}
+	}
+
+	if current != "" && cursor == -1 && len(items) > 0 {
+		cursor = 0
+	}
+
+	q.Items = items
+	q.Cursor = cursor
+
+	return current != "" && live[current]
+}
+
+// Next will move to the streamer after the current one, wrapping around to the start of
+// the queue. The new current streamer is returned, or the empty string if the queue is empty.
+func (q *PlaybackQueue) Next() string {
+	if len(q.Items) == 0 {
+		return ""
+	}
+
+	if q.Current() == "" {
+		q.Cursor = 0
+	} else {
+		q.pushHistory(q.Current())
+		q.Cursor = (q.Cursor + 1) % len(q.Items)
+	}
+
+	return q.Current()
+}
+
+// Previous will move back to the most recently played streamer that is still in the queue.
+// Previously played streamers that are no longer live are dropped from the history. The
+// new current streamer is returned, or the empty string if nothing previous is live.
+func (q *PlaybackQueue) Previous() string {
+	for len(q.History) > 0 {
+		uid := q.History[len(q.History)-1]
+		q.History = q.History[:len(q.History)-1]
+
+		index := q.indexOf(uid)
+		if index != -1 && index != q.Cursor {
+			q.Cursor = index
+			return uid
+		}
+	}
+
+	return ""
+}
+
+// JumpTo will make the streamer the current one. The return value is false if the streamer
+// is not in the queue.
+func (q *PlaybackQueue) JumpTo(streamUserID string) bool {
+	index := q.indexOf(streamUserID)
+	if index == -1 {
+		return false
+	}
+
+	if index != q.Cursor {
+		if current := q.Current(); current != "" {
+			q.pushHistory(current)
+		}
+		q.Cursor = index
+	}
+
+	return true
+}
+
+// Requeue will move the streamer so they are played after the current streamer. The
+// return value is false if the streamer is not in the queue.
+func (q *PlaybackQueue) Requeue(streamUserID string) bool {
+	index := q.indexOf(streamUserID)
+	if index == -1 {
+		return false
+	}
+	if index == q.Cursor {
+		return true
+	}
+
+	q.Items = append(q.Items[:index], q.Items[index+1:]...)
+	if index < q.Cursor {
+		q.Cursor--
+	}
+
+	position := q.Cursor + 1
+	q.Items = append(q.Items, "")
+	copy(q.Items[position+1:], q.Items[position:])
+	q.Items[position] = streamUserID
+
+	return true
+}
+
+// CountGamePlay will count a stream played in the game, an empty game ID is ignored.
+func (q *PlaybackQueue) CountGamePlay(gameID string) {
+	if gameID == "" {
+		return
+	}
+	if q.GamePlays == nil {
+		q.GamePlays = make(map[string]int)
+	}
+
+	q.GamePlays[gameID]++
+}
+
+// FavoriteGames will return the IDs of up to limit games the user played at least minPlays
+// streams in, the most played first.
+func (q *PlaybackQueue) FavoriteGames(limit, minPlays int) []string {
+	games := make([]string, 0, len(q.GamePlays))
+	for gameID, plays := range q.GamePlays {
+		if plays >= minPlays {
+			games = append(games, gameID)
+		}
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		if q.GamePlays[games[i]] != q.GamePlays[games[j]] {
+			return q.GamePlays[games[i]] > q.GamePlays[games[j]]
+		}
+		return games[i] < games[j]
+	})
+	if len(games) > limit {
+		games = games[:limit]
+	}
+
+	return games
+}
+
+// pushHistory will add the streamer to the top of the history stack, the oldest entries
+// are dropped once the stack is full.
+func (q *PlaybackQueue) pushHistory(streamUserID string) {
+	if len(q.History) > 0 && q.History[len(q.History)-1] == streamUserID {
+		return
+	}
+
+	q.History = append(q.History, streamUserID)
+	if len(q.History) > MaxQueueHistory {
+		q.History = q.History[len(q.History)-MaxQueueHistory:]
+	}
+}
+
+func (q *PlaybackQueue) indexOf(streamUserID string) int {
+	for index, uid := range q.Items {
+		if uid == streamUserID {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// clone will return a deep copy of the queue.
+func (q *PlaybackQueue) clone() *PlaybackQueue {
+	clone := &PlaybackQueue{
+		Items:   append([]string{}, q.Items...),
+		Cursor:  q.Cursor,
+		History: append([]string{}, q.History...),
+	}
+	if q.Source != nil {
+		source := *q.Source
+		source.GameIDs = append([]string(nil), q.Source.GameIDs...)
+		clone.Source = &source
+	}
+	if q.GamePlays != nil {
+		clone.GamePlays = make(map[string]int, len(q.GamePlays))
+		for gameID, plays := range q.GamePlays {
+			clone.GamePlays[gameID] = plays
+		}
+	}
+
+	return clone
+}
+
+// QueueStore keeps the PlaybackQueue for each user.
+type QueueStore interface {
+	// Queue will return the user's queue, a new empty queue is returned if the user
+	// doesn't have one.
+	Queue(userID string) (*PlaybackQueue, error)
+	// UpdateQueue will load the user's queue, apply the update to it and save the result.
+	// Concurrent updates for the same user are applied one after the other, so the update
+	// may be called more than once and should not have any side effects. If the update
+	// returns an error the queue is not saved.
+	UpdateQueue(userID string, update func(queue *PlaybackQueue) error) (*PlaybackQueue, error)
+}
+
+// memoryQueue is the queue for a single user in a MemoryQueueStore.
+type memoryQueue struct {
+	queue     *PlaybackQueue
+	expiresAt time.Time
+}
+
+// MemoryQueueStore is a QueueStore that keeps every user's queue in memory. It is safe for
+// concurrent use but the queues are lost when the process exits.
+type MemoryQueueStore struct {
+	// Expiration is how long a user's queue is kept after the last update.
+	Expiration time.Duration
+
+	mutex  sync.Mutex
+	queues map[string]*memoryQueue
+	now    func() time.Time
+}
+
+// NewMemoryQueueStore will create an empty MemoryQueueStore.
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{
+		Expiration: DefaultHistoryExpiration,
+		queues:     make(map[string]*memoryQueue),
+		now:        time.Now,
+	}
+}
+
+// queue will return a copy of the user's queue. The caller must hold the mutex.
+func (store *MemoryQueueStore) queue(userID string) *PlaybackQueue {
+	stored, ok := store.queues[userID]
+	if !ok {
+		return NewPlaybackQueue()
+	}
+
+	if !store.now().Before(stored.expiresAt) {
+		delete(store.queues, userID)
+		return NewPlaybackQueue()
+	}
+
+	return stored.queue.clone()
+}
+
+// Queue will return the user's queue.
+func (store *MemoryQueueStore) Queue(userID string) (*PlaybackQueue, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.queue(userID), nil
+}
+
+// UpdateQueue will apply the update to the user's queue while holding the store's lock.
+func (store *MemoryQueueStore) UpdateQueue(userID string, update func(queue *PlaybackQueue) error) (*PlaybackQueue, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	queue := store.queue(userID)
+	err := update(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	store.queues[userID] = &memoryQueue{
+		queue:     queue.clone(),
+		expiresAt: store.now().Add(store.Expiration),
+	}
+
+	return queue, nil
+}
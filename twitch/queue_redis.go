@@ -0,0 +1,109 @@
+package twitch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// MaxQueueUpdateAttempts is the number of times an update is retried when the queue is
+// changed by another request while the update is being applied.
+const MaxQueueUpdateAttempts = 5
+
+// ErrQueueContention is returned when a queue update could not be saved because the queue
+// kept being changed by other requests.
+var ErrQueueContention = errors.New("playback queue was changed by another request")
+
+// RedisQueueStore is a QueueStore that keeps each user's queue as JSON in Redis. The queue
+// expires after the configured expiration, which is refreshed on every update.
+type RedisQueueStore struct {
+	// Expiration is how long a user's queue is kept after the last update.
+	Expiration time.Duration
+
+	pool *redis.Pool
+}
+
+// NewRedisQueueStore will create a new RedisQueueStore connected to the Redis server at
+// the provided URL.
+func NewRedisQueueStore(redisURL string) *RedisQueueStore {
+	return &RedisQueueStore{
+		Expiration: DefaultHistoryExpiration,
+		pool:       newRedisPool(redisURL),
+	}
+}
+
+func queueKeyName(userID string) string {
+	return fmt.Sprintf("twitch_playback_queue:%s", userID)
+}
+
+// loadQueue will read the user's queue using the provided connection.
+func loadQueue(conn redis.Conn, key string) (*PlaybackQueue, error) {
+	reply, err := redis.Bytes(conn.Do("GET", key))
+	if err == redis.ErrNil {
+		return NewPlaybackQueue(), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	queue := NewPlaybackQueue()
+	err = json.Unmarshal(reply, queue)
+	if err != nil {
+		return nil, err
+	}
+
+	return queue, nil
+}
+
+// Queue will return the user's queue.
+func (store *RedisQueueStore) Queue(userID string) (*PlaybackQueue, error) {
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	return loadQueue(conn, queueKeyName(userID))
+}
+
+// UpdateQueue will apply the update to the user's queue. The key is watched while the
+// update is applied and the update is retried if another request changed the queue first.
+func (store *RedisQueueStore) UpdateQueue(userID string, update func(queue *PlaybackQueue) error) (*PlaybackQueue, error) {
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	key := queueKeyName(userID)
+	for attempt := 0; attempt < MaxQueueUpdateAttempts; attempt++ {
+		_, err := conn.Do("WATCH", key)
+		if err != nil {
+			return nil, err
+		}
+
+		queue, err := loadQueue(conn, key)
+		if err == nil {
+			err = update(queue)
+		}
+		if err != nil {
+			conn.Do("UNWATCH")
+			return nil, err
+		}
+
+		queueJSON, err := json.Marshal(queue)
+		if err != nil {
+			conn.Do("UNWATCH")
+			return nil, err
+		}
+
+		conn.Send("MULTI")
+		conn.Send("SET", key, queueJSON, "EX", int(store.Expiration.Seconds()))
+		reply, err := conn.Do("EXEC")
+		if err != nil {
+			return nil, err
+		}
+		if reply != nil {
+			return queue, nil
+		}
+		// A nil reply means the watched key changed, so try again with the new queue
+	}
+
+	return nil, ErrQueueContention
+}
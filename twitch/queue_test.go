@@ -0,0 +1,289 @@
+package twitch
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestQueue(items []string, cursor int, history []string) *PlaybackQueue {
+	return &PlaybackQueue{Items: items, Cursor: cursor, History: history}
+}
+
+func TestPlaybackQueueRefresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		queue       *PlaybackQueue
+		live        []string
+		expected    *PlaybackQueue
+		currentLive bool
+	}{
+		{
+			name:     "EmptyQueue",
+			queue:    NewPlaybackQueue(),
+			live:     []string{"a", "b"},
+			expected: newTestQueue([]string{"a", "b"}, -1, []string{}),
+		},
+		{
+			name:        "KeepsOrder",
+			queue:       newTestQueue([]string{"a", "b", "c"}, 1, []string{"a"}),
+			live:        []string{"c", "d", "b", "a"},
+			expected:    newTestQueue([]string{"a", "b", "c", "d"}, 1, []string{"a"}),
+			currentLive: true,
+		},
+		{
+			name:     "CurrentOffline",
+			queue:    newTestQueue([]string{"a", "b", "c"}, 1, []string{}),
+			live:     []string{"a", "c"},
+			expected: newTestQueue([]string{"a", "c"}, 1, []string{}),
+		},
+		{
+			name:     "LastOffline",
+			queue:    newTestQueue([]string{"a", "b", "c"}, 2, []string{}),
+			live:     []string{"a", "b"},
+			expected: newTestQueue([]string{"a", "b"}, 0, []string{}),
+		},
+		{
+			name:     "NothingLive",
+			queue:    newTestQueue([]string{"a", "b"}, 0, []string{}),
+			live:     []string{},
+			expected: newTestQueue([]string{}, -1, []string{}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			currentLive := test.queue.Refresh(test.live)
+			if currentLive != test.currentLive {
+				t.Errorf("Expected current live=%t, got %t", test.currentLive, currentLive)
+			}
+			if !reflect.DeepEqual(test.queue, test.expected) {
+				t.Errorf("Expected queue %+v, got %+v", test.expected, test.queue)
+			}
+		})
+	}
+}
+
+func TestPlaybackQueueNextAndPrevious(t *testing.T) {
+	queue := NewPlaybackQueue()
+	queue.Refresh([]string{"a", "b", "c"})
+
+	for _, expected := range []string{"a", "b", "c", "a"} {
+		if uid := queue.Next(); uid != expected {
+			t.Fatalf("Expected next stream %s, got %s", expected, uid)
+		}
+	}
+
+	for _, expected := range []string{"c", "b", "a"} {
+		if uid := queue.Previous(); uid != expected {
+			t.Fatalf("Expected previous stream %s, got %s", expected, uid)
+		}
+	}
+
+	if uid := queue.Previous(); uid != "" {
+		t.Fatalf("Expected nothing previous, got %s", uid)
+	}
+	if queue.Current() != "a" {
+		t.Fatalf("Previous with an empty history should not move the cursor")
+	}
+}
+
+func TestPlaybackQueuePreviousSkipsOfflineStreams(t *testing.T) {
+	queue := newTestQueue([]string{"a", "b", "c"}, 2, []string{"a", "b"})
+	queue.Refresh([]string{"a", "c"})
+
+	if uid := queue.Previous(); uid != "a" {
+		t.Fatalf("Expected previous stream a, got %s", uid)
+	}
+	if len(queue.History) != 0 {
+		t.Fatalf("Offline streams should be dropped from the history: %v", queue.History)
+	}
+}
+
+func TestPlaybackQueueJumpTo(t *testing.T) {
+	queue := newTestQueue([]string{"a", "b", "c"}, 0, []string{})
+
+	if queue.JumpTo("x") {
+		t.Fatalf("Jumping to a stream that isn't queued should fail")
+	}
+	if !queue.JumpTo("c") || queue.Current() != "c" {
+		t.Fatalf("Failed to jump to a queued stream")
+	}
+	if !reflect.DeepEqual(queue.History, []string{"a"}) {
+		t.Fatalf("Jumping should add the current stream to the history: %v", queue.History)
+	}
+}
+
+func TestPlaybackQueueRequeue(t *testing.T) {
+	tests := []struct {
+		name     string
+		cursor   int
+		uid      string
+		expected []string
+		current  string
+	}{
+		{name: "After", cursor: 0, uid: "d", expected: []string{"a", "d", "b", "c"}, current: "a"},
+		{name: "Before", cursor: 2, uid: "a", expected: []string{"b", "c", "a", "d"}, current: "c"},
+		{name: "Current", cursor: 1, uid: "b", expected: []string{"a", "b", "c", "d"}, current: "b"},
+		{name: "Last", cursor: 3, uid: "b", expected: []string{"a", "c", "d", "b"}, current: "d"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queue := newTestQueue([]string{"a", "b", "c", "d"}, test.cursor, []string{})
+			if !queue.Requeue(test.uid) {
+				t.Fatalf("Failed to requeue %s", test.uid)
+			}
+			if !reflect.DeepEqual(queue.Items, test.expected) || queue.Current() != test.current {
+				t.Fatalf("Expected %v with current %s, got %v with current %s", test.expected,
+					test.current, queue.Items, queue.Current())
+			}
+		})
+	}
+
+	if NewPlaybackQueue().Requeue("a") {
+		t.Fatalf("Requeueing a stream that isn't queued should fail")
+	}
+}
+
+func TestPlaybackQueueHistoryIsBounded(t *testing.T) {
+	queue := NewPlaybackQueue()
+	queue.Refresh([]string{"a", "b"})
+	for i := 0; i < MaxQueueHistory*2; i++ {
+		queue.Next()
+	}
+
+	if len(queue.History) != MaxQueueHistory {
+		t.Fatalf("Expected %d history entries, got %d", MaxQueueHistory, len(queue.History))
+	}
+}
+
+func TestPlaybackQueueFavoriteGames(t *testing.T) {
+	queue := NewPlaybackQueue()
+	for _, gameID := range []string{"a", "b", "b", "c", "c", "c", "d", "d", "d", ""} {
+		queue.CountGamePlay(gameID)
+	}
+
+	favorites := queue.FavoriteGames(2, 2)
+	if !reflect.DeepEqual(favorites, []string{"c", "d"}) {
+		t.Fatalf("Expected the most played games, got %v", favorites)
+	}
+
+	favorites = queue.FavoriteGames(5, 2)
+	if !reflect.DeepEqual(favorites, []string{"c", "d", "b"}) {
+		t.Fatalf("Expected games played less than the minimum to be left out, got %v", favorites)
+	}
+
+	if favorites := NewPlaybackQueue().FavoriteGames(2, 1); len(favorites) != 0 {
+		t.Fatalf("Expected no favorites without any plays, got %v", favorites)
+	}
+}
+
+func TestMemoryQueueStore(t *testing.T) {
+	testQueueStoreConformance(t, NewMemoryQueueStore())
+}
+
+func TestMemoryQueueStoreExpires(t *testing.T) {
+	queues := NewMemoryQueueStore()
+	queues.UpdateQueue("user", func(queue *PlaybackQueue) error {
+		queue.Refresh([]string{"a"})
+		return nil
+	})
+
+	now := time.Now().Add(DefaultHistoryExpiration + time.Minute)
+	queues.now = func() time.Time { return now }
+
+	queue, _ := queues.Queue("user")
+	if len(queue.Items) != 0 {
+		t.Fatalf("User's queue should have expired")
+	}
+}
+
+func TestRedisQueueStore(t *testing.T) {
+	history := newTestRedisHistoryStore(t)
+	testQueueStoreConformance(t, &RedisQueueStore{Expiration: DefaultHistoryExpiration, pool: history.pool})
+}
+
+// testQueueStoreConformance is the suite of tests every QueueStore implementation needs to pass.
+func testQueueStoreConformance(t *testing.T, queues QueueStore) {
+	t.Run("Empty", func(t *testing.T) {
+		queue, err := queues.Queue(createRandomMockUser().ID)
+		if err != nil || queue.Current() != "" || len(queue.Items) != 0 {
+			t.Fatalf("Expected an empty queue, got %+v (err=%v)", queue, err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		userID := createRandomMockUser().ID
+		_, err := queues.UpdateQueue(userID, func(queue *PlaybackQueue) error {
+			queue.Refresh([]string{"a", "b"})
+			queue.Next()
+			queue.Source = &QueueSource{GameID: "27471", GameName: "Minecraft"}
+			queue.CountGamePlay("27471")
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to update queue: %s", err.Error())
+		}
+
+		queue, err := queues.Queue(userID)
+		if err != nil || queue.Current() != "a" || len(queue.Items) != 2 {
+			t.Fatalf("Update was not saved: %+v (err=%v)", queue, err)
+		}
+		if queue.Source == nil || queue.Source.GameID != "27471" || queue.GamePlays["27471"] != 1 {
+			t.Fatalf("The queue's source and game plays were not saved: %+v %v", queue.Source, queue.GamePlays)
+		}
+	})
+
+	t.Run("UpdateError", func(t *testing.T) {
+		userID := createRandomMockUser().ID
+		_, err := queues.UpdateQueue(userID, func(queue *PlaybackQueue) error {
+			queue.Refresh([]string{"a"})
+			return fmt.Errorf("update failed")
+		})
+		if err == nil {
+			t.Fatalf("Expected the update error to be returned")
+		}
+
+		queue, _ := queues.Queue(userID)
+		if len(queue.Items) != 0 {
+			t.Fatalf("A failed update should not be saved")
+		}
+	})
+
+	t.Run("ConcurrentUpdates", func(t *testing.T) {
+		userID := createRandomMockUser().ID
+		queues.UpdateQueue(userID, func(queue *PlaybackQueue) error {
+			queue.Refresh([]string{"a", "b", "c"})
+			return nil
+		})
+
+		var applied int32
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := queues.UpdateQueue(userID, func(queue *PlaybackQueue) error {
+					queue.Next()
+					return nil
+				})
+				if err == nil {
+					atomic.AddInt32(&applied, 1)
+				} else if err != ErrQueueContention {
+					t.Errorf("Unexpected error updating queue: %s", err.Error())
+				}
+			}()
+		}
+		wg.Wait()
+
+		queue, _ := queues.Queue(userID)
+		// The first next only starts the queue, every other one adds to the history
+		if applied == 0 || len(queue.History) != int(applied)-1 {
+			t.Fatalf("Concurrent updates were lost, %d applied: %+v", applied, queue)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package twitch
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The headers Helix uses to describe the state of the rate limit bucket for a token.
+const (
+	RateLimitLimitHeader     = "Ratelimit-Limit"
+	RateLimitRemainingHeader = "Ratelimit-Remaining"
+	RateLimitResetHeader     = "Ratelimit-Reset"
+)
+
+// The default settings used by a RateLimiter created with NewRateLimiter.
+const (
+	DefaultRateLimitReserve = 5
+	DefaultRateLimitMaxWait = 2 * time.Second
+)
+
+// rateBucket is the last known state of the Helix rate limit bucket for a single token.
+type rateBucket struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// RateLimiter tracks the Helix rate limit buckets for each token used by a Client. Before
+// a request is sent the limiter decides if it can go now, should wait for the bucket to
+// refill, or should be shed because the remaining budget is reserved for critical requests.
+type RateLimiter struct {
+	// Reserve is the number of points in a bucket that are kept for critical requests.
+	// Non-critical requests are shed once the remaining points drop to this value.
+	Reserve int
+	// MaxWait is the longest a critical request will be held waiting for a bucket to
+	// reset. If the reset is further away the request fails with ErrRateLimited.
+	MaxWait time.Duration
+
+	mutex   sync.Mutex
+	buckets map[string]*rateBucket
+	now     func() time.Time
+	sleep   func(time.Duration)
+}
+
+// NewRateLimiter will create a new RateLimiter with the default reserve and wait settings.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		Reserve: DefaultRateLimitReserve,
+		MaxWait: DefaultRateLimitMaxWait,
+		buckets: make(map[string]*rateBucket),
+		now:     time.Now,
+		sleep:   time.Sleep,
+	}
+}
+
+// bucketKey will determine which rate limit bucket the request will be counted against.
+// Helix tracks a bucket per token, requests without a token share the client ID bucket.
+// Tokens are hashed so they aren't kept in memory by the limiter.
+func bucketKey(req *http.Request) string {
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		return "Token " + tokenKey(auth)
+	}
+
+	return "Client-ID " + req.Header.Get("Client-ID")
+}
+
+// reserve is called before a request is sent. If the bucket for the request is exhausted,
+// critical requests will wait for the reset (up to MaxWait) and non-critical requests will be
+// shed once the bucket is down to the reserve. An *Error with kind ErrRateLimited is returned
+// when the request should not be sent.
+func (l *RateLimiter) reserve(op string, req *http.Request, critical bool) error {
+	key := bucketKey(req)
+
+	l.mutex.Lock()
+	bucket, ok := l.buckets[key]
+	if !ok || !l.now().Before(bucket.reset) {
+		delete(l.buckets, key)
+		l.mutex.Unlock()
+		return nil
+	}
+
+	if bucket.remaining > l.Reserve || (critical && bucket.remaining > 0) {
+		bucket.remaining--
+		l.mutex.Unlock()
+		return nil
+	}
+
+	reset := bucket.reset
+	wait := reset.Sub(l.now())
+	l.mutex.Unlock()
+
+	if !critical {
+		return &Error{Kind: ErrRateLimited, Op: op, Reset: reset,
+			Message: "skipping non-critical request, rate limit is nearly exhausted"}
+	}
+
+	if wait > l.MaxWait {
+		return &Error{Kind: ErrRateLimited, Op: op, Reset: reset,
+			Message: "rate limit exhausted"}
+	}
+
+	l.sleep(wait)
+	return nil
+}
+
+// update will record the rate limit headers returned in the response for the request.
+func (l *RateLimiter) update(req *http.Request, resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get(RateLimitRemainingHeader))
+	if err != nil {
+		return
+	}
+
+	reset, err := strconv.ParseInt(resp.Header.Get(RateLimitResetHeader), 10, 64)
+	if err != nil {
+		return
+	}
+
+	limit, _ := strconv.Atoi(resp.Header.Get(RateLimitLimitHeader))
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.evictExpired()
+	l.buckets[bucketKey(req)] = &rateBucket{
+		limit:     limit,
+		remaining: remaining,
+		reset:     time.Unix(reset, 0),
+	}
+}
+
+// evictExpired will drop the buckets that have been reset, they would be allowed anyway and
+// tokens that are no longer used would otherwise be kept forever. The mutex must be held.
+func (l *RateLimiter) evictExpired() {
+	now := l.now()
+	for key, bucket := range l.buckets {
+		if !now.Before(bucket.reset) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// resetTime will return the time the bucket is reset from the response headers, or the
+// zero time if the header is not present.
+func resetTime(resp *http.Response) time.Time {
+	reset, err := strconv.ParseInt(resp.Header.Get(RateLimitResetHeader), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(reset, 0)
+}
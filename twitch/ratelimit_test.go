@@ -0,0 +1,131 @@
+package twitch
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRateLimiter(now time.Time) (*RateLimiter, *time.Duration) {
+	slept := time.Duration(0)
+	limiter := NewRateLimiter()
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) { slept += d }
+
+	return limiter, &slept
+}
+
+func newRateLimitedRequest(token string) *http.Request {
+	req, _ := http.NewRequest("GET", "http://localhost/users", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return req
+}
+
+func setBucket(limiter *RateLimiter, token string, remaining int, reset time.Time) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set(RateLimitLimitHeader, "800")
+	resp.Header.Set(RateLimitRemainingHeader, fmt.Sprintf("%d", remaining))
+	resp.Header.Set(RateLimitResetHeader, fmt.Sprintf("%d", reset.Unix()))
+	limiter.update(newRateLimitedRequest(token), resp)
+}
+
+func TestRateLimiterAllowsUnknownAndExpiredBuckets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limiter, _ := newTestRateLimiter(now)
+
+	if err := limiter.reserve("test", newRateLimitedRequest("a"), false); err != nil {
+		t.Fatalf("Request for an unknown bucket should be allowed: %s", err.Error())
+	}
+
+	setBucket(limiter, "a", 0, now.Add(-time.Second))
+	if err := limiter.reserve("test", newRateLimitedRequest("a"), false); err != nil {
+		t.Fatalf("Request for an expired bucket should be allowed: %s", err.Error())
+	}
+}
+
+func TestRateLimiterShedsNonCriticalRequests(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limiter, _ := newTestRateLimiter(now)
+	reset := now.Add(30 * time.Second)
+	setBucket(limiter, "a", limiter.Reserve, reset)
+
+	err := limiter.reserve("test", newRateLimitedRequest("a"), false)
+	twitchErr, ok := err.(*Error)
+	if !ok || twitchErr.Kind != ErrRateLimited || !twitchErr.Reset.Equal(reset) {
+		t.Fatalf("Expected non-critical request to be shed with the reset time, got: %v", err)
+	}
+
+	if err := limiter.reserve("test", newRateLimitedRequest("a"), true); err != nil {
+		t.Fatalf("Critical request should use the reserve: %s", err.Error())
+	}
+
+	if err := limiter.reserve("test", newRateLimitedRequest("b"), false); err != nil {
+		t.Fatalf("Buckets should be tracked per token: %s", err.Error())
+	}
+}
+
+func TestRateLimiterQueuesCriticalRequests(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limiter, slept := newTestRateLimiter(now)
+
+	setBucket(limiter, "a", 0, now.Add(time.Second))
+	if err := limiter.reserve("test", newRateLimitedRequest("a"), true); err != nil {
+		t.Fatalf("Critical request should wait for a reset within MaxWait: %s", err.Error())
+	}
+	if *slept != time.Second {
+		t.Fatalf("Expected the request to wait for the reset, waited: %v", *slept)
+	}
+
+	setBucket(limiter, "a", 0, now.Add(time.Minute))
+	err := limiter.reserve("test", newRateLimitedRequest("a"), true)
+	if KindOf(err) != ErrRateLimited {
+		t.Fatalf("Expected rate limited error when reset is too far away, got: %v", err)
+	}
+}
+
+func TestRateLimiterEvictsExpiredBuckets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limiter, _ := newTestRateLimiter(now)
+
+	setBucket(limiter, "a", 10, now.Add(-time.Second))
+	setBucket(limiter, "b", 10, now.Add(time.Minute))
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("Expected the reset bucket to be evicted, got %d buckets", len(limiter.buckets))
+	}
+
+	for key := range limiter.buckets {
+		if strings.Contains(key, "Bearer b") {
+			t.Fatalf("Expected the bucket key not to contain the token: %s", key)
+		}
+	}
+}
+
+func TestClientReturnsResetTimeOnTooManyRequests(t *testing.T) {
+	reset := time.Now().Add(time.Minute).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(RateLimitRemainingHeader, "0")
+		w.Header().Set(RateLimitResetHeader, fmt.Sprintf("%d", reset))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	_, err := client.GetUserByID("token", "")
+	twitchErr, ok := err.(*Error)
+	if !ok || twitchErr.Kind != ErrRateLimited || twitchErr.Reset.Unix() != reset {
+		t.Fatalf("Expected rate limited error with reset time, got: %v", err)
+	}
+
+	// The second request should be stopped by the limiter without reaching the server
+	_, err = client.GetUserByID("token", "")
+	twitchErr, ok = err.(*Error)
+	if !ok || twitchErr.Kind != ErrRateLimited || twitchErr.StatusCode != 0 {
+		t.Fatalf("Expected request to be blocked by the limiter, got: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package twitch
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// DefaultSearchResults is the number of channels requested from the channel search.
+const DefaultSearchResults = 10
+
+// SearchChannels will search for channels whose login or display name match the query. Only
+// the first page of results is requested since the best matches are returned first.
+func (c *Client) SearchChannels(query string) ([]*Channel, error) {
+
+	searchURL := c.APIBaseURL + fmt.Sprintf(SearchChannelsPathFormat, url.QueryEscape(query), DefaultSearchResults)
+	token, err := c.tokenFor(appToken, "")
+	if err != nil {
+		return nil, err
+	}
+
+	channelsJSON := &ChannelsResponse{}
+	err = c.getJSON("search channels", searchURL, token, channelsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.Debugf("Search channels(%s) response(%d): %+v", query, len(channelsJSON.Data), channelsJSON.Data)
+
+	return channelsJSON.Data, nil
+}
+
+// GetLatestVOD will load the most recent past broadcast for the channel with the provided
+// user ID. An ErrNotFound error is returned if the channel doesn't have any past broadcasts.
+func (c *Client) GetLatestVOD(userID string) (*Video, error) {
+
+	url := c.APIBaseURL + fmt.Sprintf(GetLatestVideosPathFormat, userID, 1)
+	token, err := c.tokenFor(appToken, "")
+	if err != nil {
+		return nil, err
+	}
+
+	videosJSON := &VideosResponse{}
+	err = c.getJSON("get videos", url, token, videosJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(videosJSON.Data) == 0 {
+		return nil, &Error{Kind: ErrNotFound, Op: "get videos", Message: "no past broadcasts for user " + userID}
+	}
+
+	return videosJSON.Data[0], nil
+}
+
+// GetVideo will load the past broadcast with the provided video ID. An ErrNotFound error is
+// returned if the video doesn't exist or was deleted.
+func (c *Client) GetVideo(id string) (*Video, error) {
+
+	url := c.APIBaseURL + fmt.Sprintf(GetVideoPathFormat, id)
+	token, err := c.tokenFor(appToken, "")
+	if err != nil {
+		return nil, err
+	}
+
+	videosJSON := &VideosResponse{}
+	err = c.getJSON("get video", url, token, videosJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(videosJSON.Data) == 0 {
+		return nil, &Error{Kind: ErrNotFound, Op: "get video", Message: "no video with ID " + id}
+	}
+
+	return videosJSON.Data[0], nil
+}
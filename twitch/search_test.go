@@ -0,0 +1,85 @@
+package twitch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearchChannels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/channels" || r.URL.Query().Get("query") != "summit one g" {
+			t.Errorf("Incorrect search channels request: %s", r.URL.String())
+		}
+		w.Write([]byte(`{"data":[{"id":"2","broadcaster_login":"summit1g","display_name":"summit1g",` +
+			`"game_name":"Just Chatting","is_live":true}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	channels, err := client.SearchChannels("summit one g")
+	if err != nil {
+		t.Fatalf("Unexpected error searching channels: %s", err.Error())
+	}
+
+	if len(channels) != 1 || channels[0].BroadcasterLogin != "summit1g" || !channels[0].IsLive {
+		t.Fatalf("Incorrect search results: %+v", channels)
+	}
+}
+
+func TestGetLatestVOD(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/videos" || query.Get("type") != "archive" || query.Get("first") != "1" {
+			t.Errorf("Incorrect videos request: %s", r.URL.String())
+		}
+		if query.Get("user_id") == "2" {
+			w.Write([]byte(`{"data":[{"id":"100","user_id":"2","title":"Last Night"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	video, err := client.GetLatestVOD("2")
+	if err != nil || video.ID != "100" || video.Title != "Last Night" {
+		t.Fatalf("Incorrect latest VOD(%v): %+v", err, video)
+	}
+
+	_, err = client.GetLatestVOD("3")
+	if KindOf(err) != ErrNotFound {
+		t.Fatalf("Expected not found error without any past broadcasts, got: %v", err)
+	}
+}
+
+func TestGetVideo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/videos" {
+			t.Errorf("Incorrect videos request: %s", r.URL.String())
+		}
+		if r.URL.Query().Get("id") == "100" {
+			w.Write([]byte(`{"data":[{"id":"100","user_id":"2","user_name":"Streamer","title":"Last Night"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+
+	video, err := client.GetVideo("100")
+	if err != nil || video.UserName != "Streamer" || video.Title != "Last Night" {
+		t.Fatalf("Incorrect video(%v): %+v", err, video)
+	}
+
+	_, err = client.GetVideo("101")
+	if KindOf(err) != ErrNotFound {
+		t.Fatalf("Expected not found error for a deleted video, got: %v", err)
+	}
+}
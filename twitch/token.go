@@ -0,0 +1,196 @@
+package twitch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAuthBaseURL is the base URL for the Twitch OAuth endpoints.
+const DefaultAuthBaseURL = "https://id.twitch.tv/oauth2"
+
+// ValidateTokenPath is the path used to validate an OAuth access token.
+const ValidateTokenPath = "/validate"
+
+// The OAuth scopes used by the skill.
+const (
+	ScopeUserReadFollows = "user:read:follows"
+)
+
+// DefaultValidationInterval is how long a successful token validation is cached before the
+// token is validated again. Twitch asks that tokens are validated at least once an hour.
+const DefaultValidationInterval = time.Hour
+
+// TokenInfo is the result of validating a user's OAuth access token.
+type TokenInfo struct {
+	ClientID  string   `json:"client_id"`
+	Login     string   `json:"login"`
+	UserID    string   `json:"user_id"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int      `json:"expires_in"`
+
+	// ExpiresAt is the time the token will expire, calculated from ExpiresIn when the
+	// token was validated.
+	ExpiresAt time.Time `json:"-"`
+
+	validatedAt time.Time
+}
+
+// MissingScopes will return any of the provided scopes that were not granted to the token.
+func (info *TokenInfo) MissingScopes(scopes ...string) []string {
+	missing := make([]string, 0)
+	for _, scope := range scopes {
+		found := false
+		for _, granted := range info.Scopes {
+			if granted == scope {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, scope)
+		}
+	}
+
+	return missing
+}
+
+// User will return a User with the details that are known from the token validation. The
+// display name is not included in the validation response so the login is used instead.
+func (info *TokenInfo) User() *User {
+	return &User{ID: info.UserID, Login: info.Login, DisplayName: info.Login}
+}
+
+// tokenKey will hash an access token so it can be used as a map key without keeping the
+// token itself in memory.
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenCache stores the results of validating users' access tokens so that every request
+// does not need an extra round trip to Twitch. The results are keyed by a hash of the token.
+type TokenCache struct {
+	// ValidationInterval is how long a validation result is used before validating again.
+	ValidationInterval time.Duration
+
+	mutex  sync.Mutex
+	tokens map[string]*TokenInfo
+	now    func() time.Time
+}
+
+// NewTokenCache will create an empty TokenCache with the default validation interval.
+func NewTokenCache() *TokenCache {
+	return &TokenCache{
+		ValidationInterval: DefaultValidationInterval,
+		tokens:             make(map[string]*TokenInfo),
+		now:                time.Now,
+	}
+}
+
+// get will return the cached validation result for the token, or nil if there is no result
+// or the cached result is too old to be used.
+func (cache *TokenCache) get(accessToken string) *TokenInfo {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	key := tokenKey(accessToken)
+	info, ok := cache.tokens[key]
+	if !ok {
+		return nil
+	}
+
+	now := cache.now()
+	if !now.Before(info.ExpiresAt) || now.Sub(info.validatedAt) >= cache.ValidationInterval {
+		delete(cache.tokens, key)
+		return nil
+	}
+
+	return info
+}
+
+func (cache *TokenCache) set(accessToken string, info *TokenInfo) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.tokens[tokenKey(accessToken)] = info
+}
+
+// Remove will drop any cached validation result for the token. This should be used when a
+// request made with the token is rejected by Twitch.
+func (cache *TokenCache) Remove(accessToken string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	delete(cache.tokens, tokenKey(accessToken))
+}
+
+// ValidateToken will validate the user's access token with Twitch and return the details
+// for the token. Results are cached in the client's TokenCache. An expired or revoked token
+// is returned as an ErrUnauthorized error.
+//
+// Refreshing user tokens is handled by Alexa account linking, so an invalid token means
+// the user needs to link their account again.
+func (c *Client) ValidateToken(accessToken string) (*TokenInfo, error) {
+	if c.Tokens != nil {
+		if info := c.Tokens.get(accessToken); info != nil {
+			return info, nil
+		}
+	}
+
+	now := time.Now()
+	if c.Tokens != nil {
+		now = c.Tokens.now()
+	}
+
+	req, err := c.newRequest(c.AuthBaseURL+ValidateTokenPath, "")
+	if err != nil {
+		return nil, &Error{Kind: ErrUnknown, Op: "validate token", Err: err}
+	}
+	req.Header.Set("Authorization", "OAuth "+accessToken)
+
+	info := &TokenInfo{}
+	err = c.decodeResponse("validate token", req, info)
+	if err != nil {
+		return nil, err
+	}
+
+	info.validatedAt = now
+	info.ExpiresAt = now.Add(time.Duration(info.ExpiresIn) * time.Second)
+	if info.ExpiresIn <= 0 {
+		return nil, &Error{Kind: ErrUnauthorized, Op: "validate token", Message: "token has expired"}
+	}
+
+	if c.Tokens != nil {
+		c.Tokens.set(accessToken, info)
+	}
+
+	c.Logger.Debugf("Validated token for user(%s) with scopes: %v", info.UserID, info.Scopes)
+
+	return info, nil
+}
+
+// RequireToken will validate the user's access token and make sure it was granted all of
+// the provided scopes. If the token is missing any scopes an ErrForbidden error is returned
+// with the missing scopes.
+func (c *Client) RequireToken(accessToken string, scopes ...string) (*TokenInfo, error) {
+	if accessToken == "" {
+		return nil, &Error{Kind: ErrUnauthorized, Op: "validate token", Message: "no access token"}
+	}
+
+	info, err := c.ValidateToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := info.MissingScopes(scopes...)
+	if len(missing) > 0 {
+		return nil, &Error{Kind: ErrForbidden, Op: "validate token", MissingScopes: missing,
+			Message: "missing scopes " + strings.Join(missing, ", ")}
+	}
+
+	return info, nil
+}
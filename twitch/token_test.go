@@ -0,0 +1,146 @@
+package twitch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newValidateServer(t *testing.T, requests *int, status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Path != ValidateTokenPath {
+			t.Errorf("Unexpected validate path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "OAuth token" {
+			t.Errorf("Incorrect Authorization header: %s", r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestValidateTokenCachesResult(t *testing.T) {
+	requests := 0
+	server := newValidateServer(t, &requests, http.StatusOK,
+		`{"client_id":"test","login":"login","user_id":"1234","scopes":["user:read:follows"],"expires_in":3600}`)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.AuthBaseURL = server.URL
+
+	for i := 0; i < 3; i++ {
+		info, err := client.ValidateToken("token")
+		if err != nil {
+			t.Fatalf("Unexpected error validating token: %s", err.Error())
+		}
+		if info.UserID != "1234" || info.User().Login != "login" {
+			t.Fatalf("Incorrect token info: %+v", info)
+		}
+	}
+
+	if requests != 1 {
+		t.Fatalf("Expected the validation to be cached, made %d requests", requests)
+	}
+
+	now := time.Now().Add(2 * time.Hour)
+	client.Tokens.now = func() time.Time { return now }
+	client.ValidateToken("token")
+	if requests != 2 {
+		t.Fatalf("Expected the token to be validated again after it expired, made %d requests", requests)
+	}
+}
+
+func TestRequireTokenRejectsInvalidToken(t *testing.T) {
+	requests := 0
+	server := newValidateServer(t, &requests, http.StatusUnauthorized,
+		`{"status":401,"message":"invalid access token"}`)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.AuthBaseURL = server.URL
+
+	_, err := client.RequireToken("token")
+	if KindOf(err) != ErrUnauthorized {
+		t.Fatalf("Expected unauthorized error for an invalid token, got: %v", err)
+	}
+
+	_, err = client.RequireToken("")
+	if KindOf(err) != ErrUnauthorized || requests != 1 {
+		t.Fatalf("Expected unauthorized error without a request for an empty token, got: %v", err)
+	}
+}
+
+func TestRequireTokenReportsMissingScopes(t *testing.T) {
+	requests := 0
+	server := newValidateServer(t, &requests, http.StatusOK,
+		`{"client_id":"test","login":"login","user_id":"1234","scopes":[],"expires_in":3600}`)
+	defer server.Close()
+
+	client := NewClient("test")
+	client.AuthBaseURL = server.URL
+
+	_, err := client.RequireToken("token", ScopeUserReadFollows)
+	twitchErr, ok := err.(*Error)
+	if !ok || twitchErr.Kind != ErrForbidden || len(twitchErr.MissingScopes) != 1 ||
+		twitchErr.MissingScopes[0] != ScopeUserReadFollows {
+		t.Fatalf("Expected forbidden error with the missing scope, got: %v", err)
+	}
+}
+
+func TestRejectedTokenIsRemovedFromCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient("test")
+	client.APIBaseURL = server.URL
+	client.Tokens.set("token", &TokenInfo{UserID: "1234", validatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour)})
+
+	client.GetUserByID("token", "")
+	if client.Tokens.get("token") != nil {
+		t.Fatalf("Expected the rejected token to be removed from the cache")
+	}
+}
+
+func TestTokenCacheHashesTokens(t *testing.T) {
+	cache := NewTokenCache()
+	cache.set("secret-token", &TokenInfo{UserID: "1234", validatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour)})
+
+	if _, ok := cache.tokens["secret-token"]; ok || len(cache.tokens) != 1 {
+		t.Fatalf("Expected the cache to be keyed by a hash of the token")
+	}
+	if info := cache.get("secret-token"); info == nil || info.UserID != "1234" {
+		t.Fatalf("Expected the cached result to be found by the token, got %+v", info)
+	}
+
+	cache.Remove("secret-token")
+	if len(cache.tokens) != 0 {
+		t.Fatalf("Expected the token to be removed from the cache")
+	}
+}
+
+func TestRequestToken(t *testing.T) {
+	cases := []struct {
+		header string
+		token  string
+	}{
+		{"Bearer token", "token"},
+		{"OAuth token", "token"},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest("GET", "http://localhost/users", nil)
+		if c.header != "" {
+			req.Header.Set("Authorization", c.header)
+		}
+		if token := requestToken(req); token != c.token {
+			t.Errorf("Incorrect token for header %q: %q", c.header, token)
+		}
+	}
+}
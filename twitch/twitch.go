@@ -2,341 +2,334 @@ package twitch
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
-	"os"
+	"net/url"
 	"strings"
-	"time"
 
-	"github.com/garyburd/redigo/redis"
 	"github.com/grafov/m3u8"
 	"github.com/kpango/glg"
 	"github.com/rking788/go-alexa/skillserver"
 )
 
-// The constant definitions for the URLs to be used to interact with the Twitch API.
+// The constant definitions for the paths to be used to interact with the Twitch API. These
+// are relative to the base URLs configured on the Client.
 const (
-	GetCurrentTwitchUserURL     = "https://api.twitch.tv/helix/users"
-	GetUserFollowsURLFormat     = "https://api.twitch.tv/helix/users/follows?from_id=%s"
-	GetLiveStreamsURLFormat     = "https://api.twitch.tv/helix/streams?type=live&user_id=%s"
-	GetChannelAccessTokenFormat = "https://api.twitch.tv/api/channels/%s/access_token?client_id=%s"
-	GetStreamsURLFormat         = "https://usher.ttvnw.net/api/channel/hls/%s.m3u8?player=twitchweb&token=%s&sig=%s&allow_audio_only=true&allow_source=false&type=any&p=%d"
+	GetCurrentTwitchUserPath      = "/users"
+	GetUserFollowsPathFormat      = "/users/follows?from_id=%s"
+	GetFollowedChannelsPathFormat = "/channels/followed?user_id=%s"
+	GetFollowedStreamsPathFormat  = "/streams/followed?user_id=%s"
+	GetLiveStreamsPathFormat      = "/streams?type=live&user_id=%s"
+	SearchChannelsPathFormat      = "/search/channels?query=%s&first=%d"
+	SearchCategoriesPathFormat    = "/search/categories?query=%s&first=%d"
+	GetGamesByNamePathFormat      = "/games?name=%s"
+	GetTopStreamsPathFormat       = "/streams?type=live&first=%d"
+	GetLatestVideosPathFormat     = "/videos?user_id=%s&type=archive&sort=time&first=%d"
+	GetVideoPathFormat            = "/videos?id=%s"
+	GetStreamsPathFormat          = "/api/channel/hls/%s.m3u8?player=twitchweb&token=%s&sig=%s&allow_audio_only=true&allow_source=false&type=any&p=%d"
+	GetVODStreamsPathFormat       = "/vod/%s.m3u8?player=twitchweb&nauth=%s&nauthsig=%s&allow_audio_only=true&allow_source=true&p=%d"
 )
 
-var redisConnPool *redis.Pool
-
-// InitEnv provides a package level initialization point for any work that is environment specific
-func InitEnv(redisURL string) {
-	redisConnPool = newRedisPool(redisURL)
-}
-
-// Redis related functions
-
-func newRedisPool(addr string) *redis.Pool {
-	// 25 is the maximum number of active connections for the Heroku Redis free tier
-	return &redis.Pool{
-		MaxIdle:     3,
-		MaxActive:   25,
-		IdleTimeout: 240 * time.Second,
-		Dial:        func() (redis.Conn, error) { return redis.DialURL(addr) },
-	}
-}
-
-// SaveUsersCurrentStream will append the provided stream's User ID to the list
-// of recently played. The list is set to automatically expire after 24 hours.
+// SaveUsersCurrentStream will append the provided stream's User ID to the user's history
+// of recently played streams. The history is set to automatically expire after 24 hours.
 // This expiration time will be updated on each stream start.
-func SaveUsersCurrentStream(user *User, stream *Stream) {
+func SaveUsersCurrentStream(history HistoryStore, user *User, stream *Stream) {
 	if user == nil || stream == nil {
 		glg.Warn("Cannot save current stream, nil user or stream param")
 		return
 	}
 
-	conn := redisConnPool.Get()
-	defer conn.Close()
-
-	listName := fmt.Sprintf("twitch_recent_streams:%s", user.ID)
-	conn.Send("MULTI")
-	// Remove previous occurrences of this stream UserID if they exist already in the list
-	conn.Send("LREM", listName, 0, stream.UserID)
-	conn.Send("LPUSH", listName, stream.UserID)
-	conn.Send("EXPIRE", listName, int((time.Hour * time.Duration(24)).Seconds()))
-	_, err := conn.Do("EXEC")
+	err := history.Push(user.ID, stream.UserID)
 	if err != nil {
 		glg.Warnf("Failed to insert recent stream: %s", err.Error())
-	}
-
-	glg.Debugf("User(%s) recent streams: %+v", user.ID, getRecentStreamUserIDs(user))
-}
-
-// getRecentStreamUserIDs will return the full list of streams tied to the
-// provided Twitch user, or an empty slice if none are present. This list will
-// expire 24 hours after the last "begin stream" operation so if the list is empty,
-// then the user has not started playing a stream within the last 24 hours.
-func getRecentStreamUserIDs(user *User) (uids []string) {
-	conn := redisConnPool.Get()
-	defer conn.Close()
-
-	listName := fmt.Sprintf("twitch_recent_streams:%s", user.ID)
-	reply, err := redis.Strings(conn.Do("LRANGE", listName, 0, -1))
-	if err != nil {
-		glg.Errorf("Failed to get last stream User ID: %s", err.Error())
 		return
 	}
 
-	return reply
+	recent, _ := history.List(user.ID)
+	glg.Debugf("User(%s) recent streams: %+v", user.ID, recent)
 }
 
-// getCurrentStreamUserID will return the User ID value for the stream the user is currently
-// viewing, if one exists; otherwise an empty string is returned.
-func getCurrentStreamUserID(user *User) (uid string) {
-
-	conn := redisConnPool.Get()
-	defer conn.Close()
-
-	listName := fmt.Sprintf("twitch_recent_streams:%s", user.ID)
-	reply, err := redis.String(conn.Do("LINDEX", listName, 0))
-	if err != nil {
-		glg.Errorf("Failed to get current stream User ID: %s", err.Error())
-	}
-
-	glg.Debugf("Found current stream ID: %s", reply)
-	return reply
-}
-
-// removeCurrentStream will pop the last stream off the list and return the previous
-// stream's User ID. This should be used when moving to the 'previous' stream. This
-// is a destructive operation, the current stream User ID will be lost.
-func removeCurrentStream(user *User) (uid string) {
-	conn := redisConnPool.Get()
-	defer conn.Close()
-
-	listName := fmt.Sprintf("twitch_recent_streams:%s", user.ID)
-	conn.Do("LPOP", listName)
-
-	reply, err := redis.String(conn.Do("LINDEX", listName, 0))
-	if err != nil {
-		glg.Errorf("Error trying to return new current stream User ID: %s", err.Error())
-		return
-	}
-
-	return reply
-}
-
-// FindLiveStreams will request the data for all currently live streams on Twitch for the
-// provided list of user IDs.
-func FindLiveStreams(client *http.Client, uids []string) (*StreamsResponse, error) {
+// findLiveStreamsBatch will request the data for all currently live streams on Twitch for
+// the provided list of user IDs. Every page of the response will be loaded. The list of IDs
+// should not be longer than MaxIDsPerRequest.
+func (c *Client) findLiveStreamsBatch(uids []string) (*StreamsResponse, error) {
 
 	joinedUIDList := strings.Join(uids, "&user_id=")
-	url := fmt.Sprintf(GetLiveStreamsURLFormat, joinedUIDList)
-	glg.Debugf("Making live stream request with url: %s", url)
-	req, err := http.NewRequest("GET", url, nil)
-
-	req.Header.Add("Client-ID", os.Getenv("TWITCH_API_CLIENT_ID"))
+	url := c.APIBaseURL + fmt.Sprintf(GetLiveStreamsPathFormat, joinedUIDList)
+	c.Logger.Debugf("Making live stream request with url: %s", url)
 
-	streamsResponse, err := client.Do(req)
+	token, err := c.tokenFor(appToken, "")
 	if err != nil {
-		fmt.Println("Failed to read the token response from Twitch!: ", err.Error())
-		return nil, errors.New("Reading response from get live streams failed: " + err.Error())
+		return nil, err
 	}
 
 	streamsJSON := &StreamsResponse{}
-	decoder := json.NewDecoder(streamsResponse.Body)
-	err = decoder.Decode(streamsJSON)
-	if err != nil {
-		glg.Errorf("Failed to decode Twitch streams JSON: %s", err.Error())
+	err = c.paginate("get live streams", url, token, func(body io.Reader) (string, int, error) {
+		page := &StreamsResponse{}
+		err := json.NewDecoder(body).Decode(page)
+		if err != nil {
+			return "", 0, err
+		}
+
+		streamsJSON.Data = append(streamsJSON.Data, page.Data...)
+		return page.Pagination.NextCursor(), len(page.Data), nil
+	})
+	if _, truncated := err.(*TruncatedError); err != nil && !truncated {
 		return nil, err
 	}
 
-	glg.Debugf("Get live streams response(%d): %+v", len(streamsJSON.Data), streamsJSON.Data)
+	c.Logger.Debugf("Get live streams response(%d): %+v", len(streamsJSON.Data), streamsJSON.Data)
 
-	return streamsJSON, nil
+	return streamsJSON, err
 }
 
 // GetUserByID will load details for the user specified by the provided id. If the ID is the
 // empty string, the current user will be determined from the provided access token.
-func GetUserByID(client *http.Client, accessToken, id string) (*User, error) {
+func (c *Client) GetUserByID(accessToken, id string) (*User, error) {
 
-	url := GetCurrentTwitchUserURL
+	url := c.APIBaseURL + GetCurrentTwitchUserPath
 	if id != "" {
 		url += "?id=" + id
 	}
-	req, err := http.NewRequest("GET", url, nil)
-
-	req.Header.Add("Authorization", "Bearer "+accessToken)
-	req.Header.Add("Client-ID", os.Getenv("TWITCH_API_CLIENT_ID"))
 
-	userResponse, err := client.Do(req)
+	token, err := c.tokenFor(userOrAppToken, accessToken)
 	if err != nil {
-		glg.Errorf("Failed to read the token response from Twitch!: %s", err.Error())
-		return nil, errors.New("Reading response from get current user failed: " + err.Error())
-	} else if userResponse.StatusCode != 200 {
-		// TODO: need to figure out why this happens so much, refresh tokens aren't working maybe?
-		glg.Errorf("Got error code from get user request: %d", userResponse.StatusCode)
+		return nil, err
 	}
 
 	userJSON := &UserResponse{}
-	decoder := json.NewDecoder(userResponse.Body)
-	err = decoder.Decode(userJSON)
+	err = c.getJSON("get user", url, token, userJSON)
 	if err != nil {
-		glg.Errorf("Failed to decode Twitch user JSON: %s", err.Error())
 		return nil, err
 	}
 
-	glg.Debugf("Get user response: %+v", userJSON.Data)
+	c.Logger.Debugf("Get user response: %+v", userJSON.Data)
+
+	if len(userJSON.Data) == 0 {
+		return nil, &Error{Kind: ErrNotFound, Op: "get user", Message: "no user with ID " + id}
+	}
 
 	return userJSON.Data[0], nil
 }
 
 // GetFollows will load the following information for the provided Twitch user.
-// The channels returned will be all of the channels followed by this user.
-func GetFollows(client *http.Client, user *User) (*Follows, error) {
+// The channels returned will be all of the channels followed by this user, every page
+// of the follows list will be requested up to the client's MaxPages limit. If the later pages
+// were shed by the rate limiter, the follows loaded are returned with a *TruncatedError.
+//
+// Deprecated: Twitch has retired the users/follows endpoint, GetFollowedChannels or
+// GetFollowedStreams should be used instead.
+func (c *Client) GetFollows(user *User) (*Follows, error) {
 
-	url := fmt.Sprintf(GetUserFollowsURLFormat, user.ID)
-	req, err := http.NewRequest("GET", url, nil)
+	url := c.APIBaseURL + fmt.Sprintf(GetUserFollowsPathFormat, user.ID)
 
-	req.Header.Add("Client-ID", os.Getenv("TWITCH_API_CLIENT_ID"))
-
-	followsResponse, err := client.Do(req)
+	token, err := c.tokenFor(appToken, "")
 	if err != nil {
-		glg.Errorf("Failed to read the token response from Twitch!: %s", err.Error())
-		return nil, errors.New("Reading response from get current user failed: " + err.Error())
+		return nil, err
 	}
 
 	followsJSON := &Follows{}
-	decoder := json.NewDecoder(followsResponse.Body)
-	err = decoder.Decode(followsJSON)
-	if err != nil {
-		glg.Errorf("Failed to decode Twitch follows JSON: %s", err.Error())
+	err = c.paginate("get follows", url, token, func(body io.Reader) (string, int, error) {
+		page := &Follows{}
+		err := json.NewDecoder(body).Decode(page)
+		if err != nil {
+			return "", 0, err
+		}
+
+		followsJSON.Total = page.Total
+		followsJSON.Data = append(followsJSON.Data, page.Data...)
+		return page.Pagination.NextCursor(), len(page.Data), nil
+	})
+	if _, truncated := err.(*TruncatedError); err != nil && !truncated {
 		return nil, err
 	}
 
-	glg.Debugf("Get follows response: %+v", followsJSON.Data)
+	c.Logger.Debugf("Get follows response(%d of %d): %+v", len(followsJSON.Data),
+		followsJSON.Total, followsJSON.Data)
 
-	return followsJSON, nil
+	return followsJSON, err
 }
 
-// GetStream will load the stream details for the provided channel name. The streamQuality parameter
-// should be either audio_only or a target video resolution.
-func GetStream(client *http.Client, channelName, accessToken, streamQuality string) (*m3u8.Variant, error) {
-	// First get the access token data for the stream
-	url := fmt.Sprintf(GetChannelAccessTokenFormat, channelName, os.Getenv("TWITCH_API_CLIENT_ID"))
+// GetStream will load the stream variants for the provided channel name and select the one
+// that best meets the constraints. Any constraints that had to be relaxed are reported in
+// the selection's FallbackReasons.
+func (c *Client) GetStream(channelName string, constraints VariantConstraints) (*VariantSelection, error) {
+	// First get the playback access token for the stream
+	token, err := c.PlaybackTokens.LiveToken(channelName)
+	if err != nil {
+		c.Logger.Errorf("Failed to load playback access token for %s: %s", channelName, err.Error())
+		return nil, err
+	}
 
-	glg.Debugf("Get channel access token url : %v", url)
-	req, err := http.NewRequest("GET", url, nil)
+	getStreamURL := c.UsherBaseURL + fmt.Sprintf(GetStreamsPathFormat, strings.ToLower(channelName),
+		url.QueryEscape(token.Value), url.QueryEscape(token.Signature), rand.Intn(999999))
 
-	accessTokenResponse, err := client.Do(req)
+	return c.getStreamVariant(getStreamURL, constraints, true)
+}
+
+// GetVODStream will load the stream variants for the VOD with the provided ID and select the
+// one that best meets the constraints.
+func (c *Client) GetVODStream(vodID string, constraints VariantConstraints) (*VariantSelection, error) {
+	token, err := c.PlaybackTokens.VODToken(vodID)
 	if err != nil {
-		glg.Errorf("Failed to read the token response from Twitch!: %s", err.Error())
-		return nil, errors.New("Reading response from get channel access token: " + err.Error())
+		c.Logger.Errorf("Failed to load playback access token for VOD %s: %s", vodID, err.Error())
+		return nil, err
 	}
 
-	channelAccessTokenJSON := &ChannelAccessToken{}
-	decoder := json.NewDecoder(accessTokenResponse.Body)
-	decoder.Decode(channelAccessTokenJSON)
+	getStreamURL := c.UsherBaseURL + fmt.Sprintf(GetVODStreamsPathFormat, vodID,
+		url.QueryEscape(token.Value), url.QueryEscape(token.Signature), rand.Intn(999999))
 
-	glg.Debugf("Get channel access token decoded response: %+v", channelAccessTokenJSON)
-
-	getStreamURL := fmt.Sprintf(GetStreamsURLFormat, channelName, channelAccessTokenJSON.Token,
-		channelAccessTokenJSON.Sig, rand.Intn(999999))
+	return c.getStreamVariant(getStreamURL, constraints, false)
+}
 
-	glg.Debugf("Get Stream URL Request : %v", getStreamURL)
+// getStreamVariant will load the master playlist from usher and select the variant that best
+// meets the constraints.
+func (c *Client) getStreamVariant(getStreamURL string, constraints VariantConstraints, live bool) (*VariantSelection, error) {
+	c.Logger.Debugf("Get Stream URL Request : %v", getStreamURL)
 	streamRequest, err := http.NewRequest("GET", getStreamURL, nil)
+	if err != nil {
+		return nil, &Error{Kind: ErrUnknown, Op: "get stream playlist", Err: err}
+	}
 
-	streamResponse, err := client.Do(streamRequest)
-	glg.Debugf("Stream response code : %d", streamResponse.StatusCode)
+	streamResponse, err := c.HTTPClient.Do(streamRequest)
+	if err != nil {
+		c.Logger.Errorf("Failed to read the stream playlist from Twitch!: %s", err.Error())
+		return nil, &Error{Kind: ErrUpstream, Op: "get stream playlist", Err: err}
+	}
+	defer streamResponse.Body.Close()
+	c.Logger.Debugf("Stream response code : %d", streamResponse.StatusCode)
+
+	if streamResponse.StatusCode < 200 || streamResponse.StatusCode > 299 {
+		usherErr := newUsherError("get stream playlist", streamResponse, live)
+		c.Logger.Errorf("Got error response from usher: %s", usherErr.Error())
+		return nil, usherErr
+	}
 
 	playlist := m3u8.NewMasterPlaylist()
 	err = playlist.DecodeFrom(streamResponse.Body, false)
 	if err != nil {
-		glg.Errorf("Failed to decode m3u file as a master playlist: %s", err.Error())
+		c.Logger.Errorf("Failed to decode m3u file as a master playlist: %s", err.Error())
+		return nil, newDecodeError("get stream playlist", err)
+	}
+
+	c.Logger.Debugf("Found %d streams variants\n", len(playlist.Variants))
+
+	selection, err := SelectVariant(ParseVariants(playlist), constraints)
+	if err != nil {
 		return nil, err
 	}
 
-	var streamVariant *m3u8.Variant
-	var audioOnlyVariant *m3u8.Variant
+	c.Logger.Debugf("Selected stream variant %s with %d fallbacks", selection.Selected.Name,
+		len(selection.Fallbacks))
+	for _, reason := range selection.FallbackReasons {
+		c.Logger.Infof("Stream variant fallback: %s", reason)
+	}
+
+	return selection, nil
+}
+
+// QueueChanges are made to the user's playback queue in the same update that applies a
+// playback command, so concurrent requests never see the queue partly changed.
+type QueueChanges struct {
+	// SetSource replaces the queue's source with Source, nil for the user's followed channels.
+	SetSource bool
+	Source    *QueueSource
+	// JumpTo is the user ID of the streamer made current before the command is applied, if
+	// they are still live.
+	JumpTo string
+	// CountGamePlay counts the chosen stream towards the user's favorite games.
+	CountGamePlay bool
+}
 
-	if len(playlist.Variants) == 0 {
-		glg.Error("Found 0 stream variants, this is a bad situation!")
-		return nil, errors.New("Zero stream variants found")
+// FindStreamForCommand will choose the stream from the live streams that should be played for
+// the playback command by moving through the user's playback queue. The queue is refreshed
+// with the live streams first, so streams keep their place in the queue between requests.
+// Any changes are made in the same update, changes can be nil.
+//
+// If the queue can't be loaded, the first live stream is used so playback still works when
+// the queue store is unavailable. nil is returned if there are no live streams, or for the
+// PREVIOUS command if none of the previously played streams are live, the response will
+// already explain this to the user.
+func FindStreamForCommand(queues QueueStore, user *User, liveStreams []*Stream, command PlaybackCommand, changes *QueueChanges, response *skillserver.EchoResponse) *Stream {
+
+	if len(liveStreams) == 0 {
+		glg.Warnf("No live streams to choose from for user(%s)", user.ID)
+		return nil
+	}
+	if changes == nil {
+		changes = &QueueChanges{}
 	}
 
-	glg.Debugf("Found %d streams variants\n", len(playlist.Variants))
+	var currentOffline, jumped bool
+	var previousUID string
+	queue, err := queues.UpdateQueue(user.ID, func(queue *PlaybackQueue) error {
+		currentOffline = !queue.Refresh(streamUserIDs(liveStreams)) && queue.Current() != ""
+		previousUID = ""
 
-	for _, variant := range playlist.Variants {
-		glg.Debugf("Variant.Video = %s", variant.Video)
-		if variant.Video == "audio_only" {
-			audioOnlyVariant = variant
+		if changes.SetSource {
+			queue.Source = changes.Source
+		}
+		jumped = changes.JumpTo != "" && queue.JumpTo(changes.JumpTo)
+		if jumped {
+			currentOffline = false
 		}
 
-		if strings.HasPrefix(variant.Video, streamQuality) {
-			glg.Debug("Found stream URL with correct prefix")
-			streamVariant = variant
-			break
+		switch command {
+		case PLAY:
+			queue.JumpTo(liveStreams[0].UserID)
+		case RESUME:
+			if queue.Current() == "" {
+				queue.Next()
+			}
+		case NEXT:
+			queue.Next()
+		case PREVIOUS:
+			previousUID = queue.Previous()
 		}
-	}
 
-	if streamVariant == nil {
-		if audioOnlyVariant != nil {
-			// If a stream did not match the requested one then fallback to audio_only...
-			glg.Debug("Didn't find a stream with the correct quality so falling back to audio")
-			streamVariant = audioOnlyVariant
-		} else {
-			// If the requested one and audio_only are both NOT available,
-			// then use the lowest quality available
-			glg.Warn("Didn't find a stream with the correct quality or audio_only so falling" +
-				" back to the last stream URL")
-			streamVariant = playlist.Variants[len(playlist.Variants)-1]
+		index := findIndexForStreamer(queue.Current(), liveStreams)
+		if changes.CountGamePlay && index != -1 && (command != PREVIOUS || previousUID != "") {
+			queue.CountGamePlay(liveStreams[index].GameID)
 		}
+
+		return nil
+	})
+	if err != nil {
+		glg.Errorf("Failed to update the playback queue: %s", err.Error())
+		return liveStreams[0]
 	}
 
-	return streamVariant, nil
-}
+	if changes.JumpTo != "" && !jumped {
+		glg.Infof("Channel %s is no longer live, resuming the queue instead", changes.JumpTo)
+	}
 
-func FindStreamForCommand(user *User, liveStreams []*Stream, command PlaybackCommand, response *skillserver.EchoResponse) *Stream {
+	streamerUserID := queue.Current()
+	if command == PREVIOUS && previousUID == "" {
+		response.OutputSpeech("It looks like none of your previously listened streams are live right now")
+		return nil
+	} else if command == RESUME && currentOffline {
+		response.OutputSpeech("It looks like that user isn't streaming right now. ")
+	}
 
-	if command == PLAY {
-		return liveStreams[0]
+	index := findIndexForStreamer(streamerUserID, liveStreams)
+	if index == -1 {
+		index = 0
 	}
+	glg.Infof("Playing stream with UserID: %s", liveStreams[index].UserID)
 
-	index := 0
-	if command == RESUME || command == NEXT {
-		streamerUserID := getCurrentStreamUserID(user)
-		if streamerUserID != "" {
-			currentStreamIndex := findIndexForStreamer(streamerUserID, liveStreams)
-			if currentStreamIndex != -1 {
-				if command == NEXT {
-					if currentStreamIndex <= (len(liveStreams) - 2) {
-						index = currentStreamIndex + 1
-						glg.Infof("Found next stream with UserID: %s", liveStreams[index].UserID)
-					}
-				} else {
-					index = currentStreamIndex
-					glg.Infof("Resuming stream with UserID: %s", liveStreams[index].UserID)
-				}
-			} else {
-				response.OutputSpeech("It looks like that user isn't streaming right now. ")
-			}
-		}
-	} else if command == PREVIOUS {
-		for {
-			prevUID := removeCurrentStream(user)
-			if prevUID == "" {
-				response.OutputSpeech("It looks like none of your previously listened streams " + "are live right now")
-				break
-			}
+	return liveStreams[index]
+}
 
-			currentStreamIndex := findIndexForStreamer(prevUID, liveStreams)
-			if currentStreamIndex != -1 {
-				index = currentStreamIndex
-				glg.Infof("Found previous stream with UserID: %s", liveStreams[index].UserID)
-				break
-			}
-		}
+// streamUserIDs will return the user IDs of the streamers for each of the streams.
+func streamUserIDs(streams []*Stream) []string {
+	uids := make([]string, 0, len(streams))
+	for _, stream := range streams {
+		uids = append(uids, stream.UserID)
 	}
 
-	return liveStreams[index]
+	return uids
 }
 
 // findIndexForStreamer will return the index in the live stream slice for the specified user
@@ -3,248 +3,180 @@ package twitch
 import (
 	"fmt"
 	"math/rand"
+	"sync/atomic"
 	"testing"
 
-	"github.com/garyburd/redigo/redis"
+	"github.com/rking788/go-alexa/skillserver"
 )
 
-func setup() {
-	InitEnv("redis://127.0.0.1:6379")
-}
-
-func teardown() {
-	clearRedisLists()
-}
+// mockSeed makes sure every mock user and stream has a unique ID.
+var mockSeed int32
 
 func TestSaveCurrentStreamWithNilDoesNothing(t *testing.T) {
 
-	setup()
-	defer teardown()
+	history := NewMemoryHistoryStore()
+	mockUser := createRandomMockUser()
 
-	SaveUsersCurrentStream(nil, createRandomMockStream())
-	conn := redisConnPool.Get()
-	defer conn.Close()
-	reply, _ := redis.Strings(conn.Do("KEYS", "*"))
-	if len(reply) != 0 {
+	SaveUsersCurrentStream(history, nil, createRandomMockStream())
+	if len(history.histories) != 0 {
 		t.Fatalf("Size of recent streams list increased when it souldn't have")
 	}
 
-	SaveUsersCurrentStream(createRandomMockUser(), nil)
-	reply, _ = redis.Strings(conn.Do("KEYS", "*"))
-	if len(reply) != 0 {
+	SaveUsersCurrentStream(history, mockUser, nil)
+	if len(history.histories) != 0 {
 		t.Fatalf("Size of recent streams list increased when it shouldn't have")
 	}
 }
 
-func TestSaveCurrentStreamAppliesExpireTime(t *testing.T) {
-	setup()
-	defer teardown()
+func TestSaveCurrentStream(t *testing.T) {
+	history := NewMemoryHistoryStore()
 
 	mockUser := createRandomMockUser()
-	listName := fmt.Sprintf("twitch_recent_streams:%s", mockUser.ID)
 	mockStream := createRandomMockStream()
 
-	SaveUsersCurrentStream(mockUser, mockStream)
-	if !validateListSize(listName, 1) {
+	SaveUsersCurrentStream(history, mockUser, mockStream)
+	if !validateListContents(t, history, mockUser, []string{mockStream.UserID}) {
 		t.Fatalf("Failed to save the mock stream to recents list")
 	}
+}
 
-	conn := redisConnPool.Get()
-	defer conn.Close()
-	ttl, err := redis.Int(conn.Do("TTL", listName))
-	if err != nil {
-		fmt.Printf("Error trying to load TTL: %s", err.Error())
-	}
+func TestFindStreamForCommandWithoutLiveStreams(t *testing.T) {
+	queues := NewMemoryQueueStore()
 
-	if ttl <= 0 {
-		t.Fatalf("Bad return value from TTL command on recent stream list: %d\n", ttl)
+	for _, command := range []PlaybackCommand{PLAY, RESUME, NEXT, PREVIOUS} {
+		stream := FindStreamForCommand(queues, createRandomMockUser(), nil, command, nil, skillserver.NewEchoResponse())
+		if stream != nil {
+			t.Fatalf("Expected no stream for command %d without live streams: %s", command, stream.UserID)
+		}
 	}
 }
 
-func TestSaveCurrentStream(t *testing.T) {
-	setup()
-	defer teardown()
-
-	mockUser1 := createRandomMockUser()
-	listName1 := fmt.Sprintf("twitch_recent_streams:%s", mockUser1.ID)
-	mockUser2 := createRandomMockUser()
-	listName2 := fmt.Sprintf("twitch_recent_streams:%s", mockUser2.ID)
-
-	mockStream1 := createRandomMockStream()
-	mockStream2 := createRandomMockStream()
-
-	SaveUsersCurrentStream(mockUser1, mockStream1)
-	if !validateListSize(listName1, 1) ||
-		!validateListSize(listName2, 0) {
-		t.Fatalf("Failed to save current stream")
-	}
+func TestFindStreamForCommandWithoutQueue(t *testing.T) {
+	queues := NewMemoryQueueStore()
+	liveStreams := []*Stream{createRandomMockStream(), createRandomMockStream()}
 
-	SaveUsersCurrentStream(mockUser2, mockStream2)
-	if !validateListSize(listName2, 1) ||
-		!validateListSize(listName1, 1) {
-		t.Fatalf("Failed to save second recent stream")
+	for _, command := range []PlaybackCommand{PLAY, RESUME, NEXT} {
+		stream := FindStreamForCommand(queues, createRandomMockUser(), liveStreams, command, nil, skillserver.NewEchoResponse())
+		if stream != liveStreams[0] {
+			t.Fatalf("Expected the first live stream for command %d without a queue", command)
+		}
 	}
 
-	SaveUsersCurrentStream(mockUser1, mockStream2)
-	if !validateListSize(listName1, 2) ||
-		!validateListSize(listName2, 1) {
-		t.Fatalf("Inserted recent stream into the wrong user's list")
+	response := skillserver.NewEchoResponse()
+	if stream := FindStreamForCommand(queues, createRandomMockUser(), liveStreams, PREVIOUS, nil, response); stream != nil {
+		t.Fatalf("Previous without a queue should not choose a stream: %s", stream.UserID)
 	}
-
-	if !validateListContents(listName1, []string{mockStream1.UserID, mockStream2.UserID}) ||
-		!validateListContents(listName2, []string{mockStream2.UserID}) {
-		t.Fatalf("The user's recent lists did not contain the correct stream user IDs")
+	if response.Response.OutputSpeech == nil {
+		t.Fatalf("Previous without a queue should explain that nothing previous is live")
 	}
 }
 
-func TestGetUsersRecentStreamUserIDs(t *testing.T) {
-	setup()
-	defer teardown()
-
+func TestFindStreamForCommandWithQueue(t *testing.T) {
+	queues := NewMemoryQueueStore()
 	mockUser := createRandomMockUser()
-	listName := fmt.Sprintf("twitch_recent_streams:%s", mockUser.ID)
-	mockStream1 := createRandomMockStream()
-	mockStream2 := createRandomMockStream()
-	mockStream3 := createRandomMockStream()
+	liveStreams := []*Stream{createRandomMockStream(), createRandomMockStream(), createRandomMockStream()}
 
-	if !validateListSize(listName, 0) {
-		t.Fatalf("Error initial state, there are recent streams when it should be an empty list")
+	if stream := FindStreamForCommand(queues, mockUser, liveStreams, PLAY, nil, skillserver.NewEchoResponse()); stream != liveStreams[0] {
+		t.Fatalf("Play did not return the first live stream: %s", stream.UserID)
 	}
 
-	streamerUserID := getRecentStreamUserIDs(mockUser)
-	if len(streamerUserID) != 0 {
-		t.Fatalf("Should have returned nil slice when no active stream sessions, it did NOT")
+	if stream := FindStreamForCommand(queues, mockUser, liveStreams, NEXT, nil, skillserver.NewEchoResponse()); stream != liveStreams[1] {
+		t.Fatalf("Next did not return the stream after the current stream: %s", stream.UserID)
 	}
 
-	SaveUsersCurrentStream(mockUser, mockStream1)
-	if !validateListSize(listName, 1) {
-		t.Fatalf("Failed to save first recent stream")
+	if stream := FindStreamForCommand(queues, mockUser, liveStreams, RESUME, nil, skillserver.NewEchoResponse()); stream != liveStreams[1] {
+		t.Fatalf("Resume did not return the current stream: %s", stream.UserID)
 	}
 
-	userIDs := getRecentStreamUserIDs(mockUser)
-	if userIDs[0] != mockStream1.UserID {
-		t.Fatalf("List of streamer's user IDs is incorrect for recent streams with a single entry")
+	if stream := FindStreamForCommand(queues, mockUser, liveStreams, PREVIOUS, nil, skillserver.NewEchoResponse()); stream != liveStreams[0] {
+		t.Fatalf("Previous did not return the previously played stream: %s", stream.UserID)
 	}
+}
 
-	SaveUsersCurrentStream(mockUser, mockStream2)
-	if !validateListSize(listName, 2) {
-		t.Fatalf("Failed to save second recent stream, incorrect list size")
-	}
+func TestFindStreamForCommandKeepsOrderWhenLiveOrderChanges(t *testing.T) {
+	queues := NewMemoryQueueStore()
+	mockUser := createRandomMockUser()
+	liveStreams := []*Stream{createRandomMockStream(), createRandomMockStream(), createRandomMockStream()}
 
-	userIDs = getRecentStreamUserIDs(mockUser)
-	if userIDs[0] != mockStream2.UserID ||
-		userIDs[1] != mockStream1.UserID {
-		t.Fatalf("List of streamer's user IDs is incorrect for recent streams with a second entry")
-	}
+	FindStreamForCommand(queues, mockUser, liveStreams, PLAY, nil, skillserver.NewEchoResponse())
 
-	SaveUsersCurrentStream(mockUser, mockStream3)
-	if !validateListSize(listName, 3) {
-		t.Fatalf("Failed to save third recent stream, incorrect list size")
+	// Twitch now returns the streams in a different order, next should still move through
+	// the queue in the original order
+	reordered := []*Stream{liveStreams[2], liveStreams[0], liveStreams[1]}
+	if stream := FindStreamForCommand(queues, mockUser, reordered, NEXT, nil, skillserver.NewEchoResponse()); stream != liveStreams[1] {
+		t.Fatalf("Next should follow the queue order, got %s", stream.UserID)
 	}
-
-	userIDs = getRecentStreamUserIDs(mockUser)
-	if userIDs[0] != mockStream3.UserID ||
-		userIDs[1] != mockStream2.UserID ||
-		userIDs[2] != mockStream1.UserID {
-		t.Fatalf("List of streamer's user IDs is incorrect for recent streams with a third entry")
+	if stream := FindStreamForCommand(queues, mockUser, reordered, NEXT, nil, skillserver.NewEchoResponse()); stream != liveStreams[2] {
+		t.Fatalf("Next should follow the queue order, got %s", stream.UserID)
 	}
 }
 
-func TestGetUsersCurrentStream(t *testing.T) {
-	setup()
-	defer teardown()
-
+func TestFindStreamForCommandResumeWhenCurrentOffline(t *testing.T) {
+	queues := NewMemoryQueueStore()
 	mockUser := createRandomMockUser()
-	listName := fmt.Sprintf("twitch_recent_streams:%s", mockUser.ID)
-	mockStream1 := createRandomMockStream()
-	mockStream2 := createRandomMockStream()
-	mockStream3 := createRandomMockStream()
-
-	if !validateListSize(listName, 0) {
-		t.Fatalf("Error initial state, there are recent streams when it should be an empty list")
-	}
+	liveStreams := []*Stream{createRandomMockStream(), createRandomMockStream(), createRandomMockStream()}
 
-	streamerUserID := getCurrentStreamUserID(mockUser)
-	if streamerUserID != "" {
-		t.Fatalf("Should have returned empty string when no active stream sessions, it did NOT")
-	}
+	FindStreamForCommand(queues, mockUser, liveStreams, PLAY, nil, skillserver.NewEchoResponse())
 
-	SaveUsersCurrentStream(mockUser, mockStream1)
-	if !validateListSize(listName, 1) {
-		t.Fatalf("Failed to save first recent stream")
+	response := skillserver.NewEchoResponse()
+	stream := FindStreamForCommand(queues, mockUser, liveStreams[1:], RESUME, nil, response)
+	if stream != liveStreams[1] {
+		t.Fatalf("Resume should move on to the next stream in the queue, got %s", stream.UserID)
 	}
-
-	streamerUserID = getCurrentStreamUserID(mockUser)
-	if streamerUserID != mockStream1.UserID {
-		t.Fatalf("Failed to retrieve new current stream after inserting one into list")
-	}
-
-	SaveUsersCurrentStream(mockUser, mockStream2)
-	if !validateListSize(listName, 2) {
-		t.Fatalf("Failed to save second recent stream, incorrect list size")
+	if response.Response.OutputSpeech == nil {
+		t.Fatalf("Resume should explain that the current stream is offline")
 	}
+}
 
-	streamerUserID = getCurrentStreamUserID(mockUser)
-	if streamerUserID != mockStream2.UserID {
-		t.Fatalf("Failed to retrieve new current stream after inserting one into list")
-	}
+func TestFindStreamForCommandPreviousSkipsOfflineStreams(t *testing.T) {
+	queues := NewMemoryQueueStore()
+	mockUser := createRandomMockUser()
+	liveStreams := []*Stream{createRandomMockStream(), createRandomMockStream(), createRandomMockStream()}
 
-	SaveUsersCurrentStream(mockUser, mockStream3)
-	if !validateListSize(listName, 3) {
-		t.Fatalf("Failed to save third recent stream, incorrect list size")
-	}
+	FindStreamForCommand(queues, mockUser, liveStreams, PLAY, nil, skillserver.NewEchoResponse())
+	FindStreamForCommand(queues, mockUser, liveStreams, NEXT, nil, skillserver.NewEchoResponse())
+	FindStreamForCommand(queues, mockUser, liveStreams, NEXT, nil, skillserver.NewEchoResponse())
 
-	streamerUserID = getCurrentStreamUserID(mockUser)
-	if streamerUserID != mockStream3.UserID {
-		t.Fatalf("Failed to retrieve new current stream after inserting one into list")
+	// The stream played before the current one went offline
+	stillLive := []*Stream{liveStreams[0], liveStreams[2]}
+	if stream := FindStreamForCommand(queues, mockUser, stillLive, PREVIOUS, nil, skillserver.NewEchoResponse()); stream != liveStreams[0] {
+		t.Fatalf("Previous did not skip the offline stream")
 	}
 }
 
-func TestRemoveCurrentStream(t *testing.T) {
+// countingQueueStore is a QueueStore that counts the updates made to the queues.
+type countingQueueStore struct {
+	*MemoryQueueStore
+	updates int
+}
 
-	setup()
-	defer teardown()
+func (store *countingQueueStore) UpdateQueue(userID string, update func(queue *PlaybackQueue) error) (*PlaybackQueue, error) {
+	store.updates++
+	return store.MemoryQueueStore.UpdateQueue(userID, update)
+}
 
+func TestFindStreamForCommandWithChanges(t *testing.T) {
+	queues := &countingQueueStore{MemoryQueueStore: NewMemoryQueueStore()}
 	mockUser := createRandomMockUser()
-	listName := fmt.Sprintf("twitch_recent_streams:%s", mockUser.ID)
-	mockStream1 := createRandomMockStream()
-	mockStream2 := createRandomMockStream()
-	mockStream3 := createRandomMockStream()
+	liveStreams := []*Stream{createRandomMockStream(), createRandomMockStream()}
+	liveStreams[1].GameID = "27471"
 
-	if !validateListSize(listName, 0) {
-		t.Fatalf("Error bad initial test state, list of recent streams is NOT empty")
+	source := &QueueSource{GameID: "27471", GameName: "Minecraft"}
+	changes := &QueueChanges{SetSource: true, Source: source, JumpTo: liveStreams[1].UserID, CountGamePlay: true}
+	stream := FindStreamForCommand(queues, mockUser, liveStreams, RESUME, changes, skillserver.NewEchoResponse())
+	if stream != liveStreams[1] {
+		t.Fatalf("Expected the stream that was jumped to, got %s", stream.UserID)
 	}
 
-	nextUID := removeCurrentStream(mockUser)
-	if nextUID != "" {
-		t.Fatalf("Error, removing a current stream should have returned empty string for the next UID but did not.")
-	}
-
-	SaveUsersCurrentStream(mockUser, mockStream1)
-	SaveUsersCurrentStream(mockUser, mockStream2)
-	SaveUsersCurrentStream(mockUser, mockStream3)
-
-	nextUID = removeCurrentStream(mockUser)
-	if nextUID != mockStream2.UserID {
-		t.Fatalf("Error: incorrect next stream UID returned after removing current"+
-			" stream from list. Expected=%s, Actual=%s", mockStream2.UserID, nextUID)
-	}
-
-	nextUID = removeCurrentStream(mockUser)
-	if nextUID != mockStream1.UserID {
-		t.Fatalf("Error: incorrect next stream UID returned after removing current"+
-			" stream from list. Expected=%s, Actual=%s", mockStream1.UserID, nextUID)
-	}
-
-	nextUID = removeCurrentStream(mockUser)
-	if nextUID != "" {
-		t.Fatalf("Error: removed all current streams but still got a non-empty string for" +
-			" the next stream UID value.")
+	queue, _ := queues.Queue(mockUser.ID)
+	if queues.updates != 1 || queue.Source == nil || queue.Source.GameID != "27471" || queue.GamePlays["27471"] != 1 {
+		t.Fatalf("Expected every change in a single update(%d): source=%+v plays=%v", queues.updates,
+			queue.Source, queue.GamePlays)
 	}
 }
 
 func createRandomMockUser() *User {
-	seed := fmt.Sprintf("%d", rand.Intn(1000))
+	seed := fmt.Sprintf("%d-%d", atomic.AddInt32(&mockSeed, 1), rand.Intn(1000))
 	return &User{
 		ID:              "id" + seed,
 		Login:           "login" + seed,
@@ -255,7 +187,7 @@ func createRandomMockUser() *User {
 }
 
 func createRandomMockStream() *Stream {
-	seed := fmt.Sprintf("%d", rand.Intn(1000))
+	seed := fmt.Sprintf("%d-%d", atomic.AddInt32(&mockSeed, 1), rand.Intn(1000))
 	return &Stream{
 		ID:           "id" + seed,
 		UserID:       "userID" + seed,
@@ -267,22 +199,30 @@ func createRandomMockStream() *Stream {
 	}
 }
 
-func validateListSize(listName string, expected int) bool {
+func validateListSize(t *testing.T, history HistoryStore, user *User, expected int) bool {
+	list, err := history.List(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to list recent streams: %s", err.Error())
+	}
 
-	return true
+	return len(list) == expected
 }
 
-func validateListContents(listName string, expected []string) bool {
-
-	return true
-}
+func validateListContents(t *testing.T, history HistoryStore, user *User, expected []string) bool {
+	list, err := history.List(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to list recent streams: %s", err.Error())
+	}
 
-func clearRedisLists() {
-	conn := redisConnPool.Get()
-	defer conn.Close()
-	reply, _ := redis.Strings(conn.Do("KEYS", "*"))
+	if len(list) != len(expected) {
+		return false
+	}
 
-	for _, key := range reply {
-		conn.Do("DEL", key)
+	for i := range list {
+		if list[i] != expected[i] {
+			return false
+		}
 	}
+
+	return true
 }
@@ -1,6 +1,9 @@
 package twitch
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type PlaybackCommand int
 
@@ -15,25 +18,39 @@ const (
 
 // StreamsResponse container around the Twitch streams response.
 type StreamsResponse struct {
-	Data []*Stream
-	*Pagination
+	Data       []*Stream   `json:"data"`
+	Pagination *Pagination `json:"pagination"`
 }
 
 // Stream describes the properties for a particular stream on Twitch
 type Stream struct {
-	ID           string   `json:"id"`
-	UserID       string   `json:"user_id"`
-	CommunityIDs []string `json:"community_ids"`
-	Type         string   `json:"type"`
-	Title        string   `json:"title"`
-	ViewerCount  int      `json:"viewer_count"`
-	ThumbnailURL string   `json:"thumbnail_url"`
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	UserLogin    string    `json:"user_login"`
+	UserName     string    `json:"user_name"`
+	GameID       string    `json:"game_id"`
+	GameName     string    `json:"game_name"`
+	CommunityIDs []string  `json:"community_ids"`
+	Type         string    `json:"type"`
+	Title        string    `json:"title"`
+	ViewerCount  int       `json:"viewer_count"`
+	StartedAt    time.Time `json:"started_at"`
+	ThumbnailURL string    `json:"thumbnail_url"`
 }
 
 func (s *Stream) String() string {
 	return fmt.Sprintf("%+v", *s)
 }
 
+// Uptime will return how long the stream has been live, or 0 if the start time is not known.
+func (s *Stream) Uptime() time.Duration {
+	if s.StartedAt.IsZero() {
+		return 0
+	}
+
+	return time.Since(s.StartedAt)
+}
+
 // UserResponse is a container around the response from the Twitch /users endpoint
 type UserResponse struct {
 	Data []*User
@@ -54,7 +71,9 @@ func (u *User) String() string {
 
 // Follows is a wrapper around the response when requesting a set of follower relationships
 type Follows struct {
-	Data []*Follow
+	Total      int         `json:"total"`
+	Data       []*Follow   `json:"data"`
+	Pagination *Pagination `json:"pagination"`
 }
 
 // FollowIDsList will extract the user IDs from the calling Follows struct into a single slice
@@ -79,15 +98,102 @@ func (f *Follow) String() string {
 	return fmt.Sprintf("%+v", *f)
 }
 
+// FollowedChannelsResponse is a wrapper around the response when requesting the channels
+// followed by a user.
+type FollowedChannelsResponse struct {
+	Total      int                `json:"total"`
+	Data       []*FollowedChannel `json:"data"`
+	Pagination *Pagination        `json:"pagination"`
+}
+
+// FollowedChannel describes a single channel followed by a user.
+type FollowedChannel struct {
+	BroadcasterID    string `json:"broadcaster_id"`
+	BroadcasterLogin string `json:"broadcaster_login"`
+	BroadcasterName  string `json:"broadcaster_name"`
+	FollowedAt       string `json:"followed_at"`
+}
+
+func (f *FollowedChannel) String() string {
+	return fmt.Sprintf("%+v", *f)
+}
+
+// ChannelsResponse is a wrapper around the response when searching for channels.
+type ChannelsResponse struct {
+	Data       []*Channel  `json:"data"`
+	Pagination *Pagination `json:"pagination"`
+}
+
+// Channel describes a channel returned from a channel search. The channel's stream details
+// are only set if IsLive is true.
+type Channel struct {
+	ID               string `json:"id"`
+	BroadcasterLogin string `json:"broadcaster_login"`
+	DisplayName      string `json:"display_name"`
+	GameID           string `json:"game_id"`
+	GameName         string `json:"game_name"`
+	Title            string `json:"title"`
+	IsLive           bool   `json:"is_live"`
+	StartedAt        string `json:"started_at"`
+}
+
+func (c *Channel) String() string {
+	return fmt.Sprintf("%+v", *c)
+}
+
+// GamesResponse is a wrapper around the response when searching for categories or
+// requesting games.
+type GamesResponse struct {
+	Data       []*Game     `json:"data"`
+	Pagination *Pagination `json:"pagination"`
+}
+
+// Game describes a game or other category (e.g. Just Chatting) that streams are listed in.
+type Game struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	BoxArtURL string `json:"box_art_url"`
+}
+
+func (g *Game) String() string {
+	return fmt.Sprintf("%+v", *g)
+}
+
+// VideosResponse is a wrapper around the response when requesting a channel's videos.
+type VideosResponse struct {
+	Data       []*Video    `json:"data"`
+	Pagination *Pagination `json:"pagination"`
+}
+
+// Video describes a single VOD on Twitch.
+type Video struct {
+	ID           string `json:"id"`
+	UserID       string `json:"user_id"`
+	UserLogin    string `json:"user_login"`
+	UserName     string `json:"user_name"`
+	Title        string `json:"title"`
+	CreatedAt    string `json:"created_at"`
+	Duration     string `json:"duration"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Type         string `json:"type"`
+}
+
+func (v *Video) String() string {
+	return fmt.Sprintf("%+v", *v)
+}
+
 // Pagination wraps the cursor used to perform pagination on endpoints that support it. The
 // cursor should be used in following requests to indicate the current page.
 type Pagination struct {
 	Cursor string `json:"cursor"`
 }
 
-// ChannelAccessToken is used for loading the stream URL for a specific channel. For some reason
-// this type of request auth needs to be used instead of the other oauth process.
-type ChannelAccessToken struct {
-	Sig   string `json:"sig"`
-	Token string `json:"token"`
+// NextCursor will return the cursor for the next page, or the empty string if there are no
+// more pages. It is safe to call on a nil Pagination.
+func (p *Pagination) NextCursor() string {
+	if p == nil {
+		return ""
+	}
+
+	return p.Cursor
 }
@@ -0,0 +1,223 @@
+package twitch
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafov/m3u8"
+)
+
+// AudioOnlyVariantName is the name usher uses for the audio only variant of a stream.
+const AudioOnlyVariantName = "audio_only"
+
+// DefaultFrameRate is used for variants that don't include a frame rate in their name.
+const DefaultFrameRate = 30
+
+// variantNameRegex matches variant names like "720p60", "480p" or "1080p60 (source)".
+var variantNameRegex = regexp.MustCompile(`^(\d+)p(\d+)?`)
+
+// StreamVariant describes a single variant in the master playlist for a stream.
+type StreamVariant struct {
+	Name      string
+	URI       string
+	Width     int
+	Height    int
+	FrameRate int
+	Bandwidth uint32
+	Codecs    string
+	AudioOnly bool
+	Source    bool
+}
+
+func (v *StreamVariant) String() string {
+	return fmt.Sprintf("%+v", *v)
+}
+
+// VariantConstraints describe the variant a device would like to play. The zero value has
+// no constraints and will select the highest quality video variant.
+type VariantConstraints struct {
+	// MaxHeight is the tallest video that should be selected, 0 for no limit.
+	MaxHeight int
+	// MaxBandwidth is the highest bandwidth (in bits per second) that should be selected,
+	// 0 for no limit.
+	MaxBandwidth uint32
+	// AudioOnly should be set if only the audio should be played.
+	AudioOnly bool
+	// PreferHighFrameRate will select 60fps variants over 30fps variants of the same height.
+	// Otherwise variants of the same height are kept in playlist order.
+	PreferHighFrameRate bool
+}
+
+// VariantSelection is the result of selecting a variant from a master playlist.
+type VariantSelection struct {
+	// Selected is the variant that best matches the constraints.
+	Selected *StreamVariant
+	// Fallbacks are the other variants that can be tried, in order, if the selected variant
+	// can't be played.
+	Fallbacks []*StreamVariant
+	// FallbackReasons describe each constraint that could not be met and had to be relaxed
+	// to select a variant. This is empty when the selected variant meets every constraint.
+	FallbackReasons []string
+}
+
+// ParseVariants will convert the variants in the master playlist into StreamVariants ranked
+// from highest to lowest resolution, variants of the same height are kept in playlist order.
+// Audio only variants are ranked last.
+func ParseVariants(playlist *m3u8.MasterPlaylist) []*StreamVariant {
+	variants := make([]*StreamVariant, 0, len(playlist.Variants))
+	for _, v := range playlist.Variants {
+		if v == nil {
+			continue
+		}
+		variants = append(variants, parseVariant(v))
+	}
+
+	sort.SliceStable(variants, func(i, j int) bool {
+		return rankVariants(variants[i], variants[j], false)
+	})
+
+	return variants
+}
+
+func parseVariant(v *m3u8.Variant) *StreamVariant {
+	variant := &StreamVariant{
+		Name:      v.Video,
+		URI:       v.URI,
+		Bandwidth: v.Bandwidth,
+		Codecs:    v.Codecs,
+		AudioOnly: v.Video == AudioOnlyVariantName,
+	}
+
+	// The EXT-X-MEDIA name is more descriptive for the source variant ("1080p60 (source)")
+	// whose VIDEO group is just "chunked".
+	name := v.Video
+	for _, alternative := range v.Alternatives {
+		if alternative != nil && alternative.Name != "" {
+			name = alternative.Name
+			break
+		}
+	}
+	variant.Source = v.Video == "chunked" || strings.Contains(name, "source")
+
+	fmt.Sscanf(v.Resolution, "%dx%d", &variant.Width, &variant.Height)
+	if matches := variantNameRegex.FindStringSubmatch(name); matches != nil {
+		if variant.Height == 0 {
+			variant.Height, _ = strconv.Atoi(matches[1])
+		}
+		variant.FrameRate, _ = strconv.Atoi(matches[2])
+	}
+	if variant.FrameRate == 0 && !variant.AudioOnly {
+		variant.FrameRate = DefaultFrameRate
+	}
+	if variant.Name == "" {
+		variant.Name = name
+	}
+
+	return variant
+}
+
+// rankVariants will return true if a should be ranked ahead of b. Variants of the same height
+// are only reordered when the higher frame rate is preferred, otherwise they tie so a stable
+// sort keeps them in playlist order.
+func rankVariants(a, b *StreamVariant, preferHighFrameRate bool) bool {
+	if a.AudioOnly != b.AudioOnly {
+		return !a.AudioOnly
+	}
+	if a.Height != b.Height {
+		return a.Height > b.Height
+	}
+	if !preferHighFrameRate {
+		return false
+	}
+	if a.FrameRate != b.FrameRate {
+		return a.FrameRate > b.FrameRate
+	}
+
+	return a.Bandwidth > b.Bandwidth
+}
+
+// meets will return true if the variant satisfies all of the constraints.
+func (constraints VariantConstraints) meets(v *StreamVariant) bool {
+	if constraints.AudioOnly {
+		return v.AudioOnly
+	}
+
+	return !v.AudioOnly &&
+		(constraints.MaxHeight == 0 || v.Height <= constraints.MaxHeight) &&
+		(constraints.MaxBandwidth == 0 || v.Bandwidth <= constraints.MaxBandwidth)
+}
+
+// SelectVariant will choose the variant that best meets the constraints. If no variant meets
+// every constraint they are relaxed (and reported in FallbackReasons): audio only requests
+// fall back to the lowest quality video, video requests fall back to the lowest quality video
+// that is available and then to audio only.
+func SelectVariant(variants []*StreamVariant, constraints VariantConstraints) (*VariantSelection, error) {
+	if len(variants) == 0 {
+		return nil, &Error{Kind: ErrNotFound, Op: "select variant", Message: "zero stream variants found"}
+	}
+
+	ranked := make([]*StreamVariant, len(variants))
+	copy(ranked, variants)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return rankVariants(ranked[i], ranked[j], constraints.PreferHighFrameRate)
+	})
+
+	matching := make([]*StreamVariant, 0, len(ranked))
+	others := make([]*StreamVariant, 0, len(ranked))
+	for _, v := range ranked {
+		if constraints.meets(v) {
+			matching = append(matching, v)
+		} else {
+			others = append(others, v)
+		}
+	}
+
+	selection := &VariantSelection{FallbackReasons: make([]string, 0)}
+	if len(matching) > 0 {
+		selection.Selected = matching[0]
+		selection.Fallbacks = append(matching[1:], lowerQualityFirst(others)...)
+		return selection, nil
+	}
+
+	// Nothing met every constraint so the closest variant is used instead, which is the
+	// lowest quality video or audio only if there are no video variants.
+	fallbacks := lowerQualityFirst(others)
+	if constraints.AudioOnly {
+		selection.FallbackReasons = append(selection.FallbackReasons,
+			"audio only is not available, using the lowest quality video")
+	} else if fallbacks[0].AudioOnly {
+		selection.FallbackReasons = append(selection.FallbackReasons,
+			"no video variants are available, using audio only")
+	} else {
+		selection.FallbackReasons = append(selection.FallbackReasons,
+			fmt.Sprintf("no variant within the requested limits, using %s", fallbacks[0].Name))
+	}
+
+	selection.Selected = fallbacks[0]
+	selection.Fallbacks = fallbacks[1:]
+
+	return selection, nil
+}
+
+// lowerQualityFirst will order the variants so the lowest quality video comes first,
+// followed by the higher quality videos and then the audio only variants.
+func lowerQualityFirst(variants []*StreamVariant) []*StreamVariant {
+	video := make([]*StreamVariant, 0, len(variants))
+	audio := make([]*StreamVariant, 0)
+	for _, v := range variants {
+		if v.AudioOnly {
+			audio = append(audio, v)
+		} else {
+			video = append(video, v)
+		}
+	}
+
+	sort.SliceStable(video, func(i, j int) bool {
+		return rankVariants(video[j], video[i], true)
+	})
+
+	return append(video, audio...)
+}
@@ -0,0 +1,132 @@
+package twitch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grafov/m3u8"
+)
+
+func parseTestVariants(t *testing.T, playlist string) []*StreamVariant {
+	master := m3u8.NewMasterPlaylist()
+	err := master.DecodeFrom(strings.NewReader(playlist), false)
+	if err != nil {
+		t.Fatalf("Failed to decode test playlist: %s", err.Error())
+	}
+
+	return ParseVariants(master)
+}
+
+func variantNames(variants []*StreamVariant) []string {
+	names := make([]string, 0, len(variants))
+	for _, v := range variants {
+		names = append(names, v.Name)
+	}
+
+	return names
+}
+
+func TestParseVariants(t *testing.T) {
+	variants := parseTestVariants(t, testMasterPlaylist)
+
+	names := strings.Join(variantNames(variants), ",")
+	if names != "chunked,720p60,720p30,160p30,audio_only" {
+		t.Fatalf("Variants are not ranked correctly: %s", names)
+	}
+
+	source := variants[0]
+	if !source.Source || source.Height != 1080 || source.Width != 1920 || source.FrameRate != 60 ||
+		source.Bandwidth != 6000000 || source.Codecs != "avc1.64002A,mp4a.40.2" {
+		t.Fatalf("Incorrect source variant: %+v", source)
+	}
+
+	if variants[2].FrameRate != 30 || variants[2].Height != 720 {
+		t.Fatalf("Incorrect 720p30 variant: %+v", variants[2])
+	}
+
+	if !variants[4].AudioOnly || variants[4].FrameRate != 0 {
+		t.Fatalf("Incorrect audio only variant: %+v", variants[4])
+	}
+}
+
+func TestSelectVariant(t *testing.T) {
+	variants := parseTestVariants(t, testMasterPlaylist)
+
+	cases := []struct {
+		constraints VariantConstraints
+		selected    string
+		fallbacks   string
+		relaxed     bool
+	}{
+		{VariantConstraints{}, "chunked", "720p60,720p30,160p30,audio_only", false},
+		{VariantConstraints{PreferHighFrameRate: true}, "chunked", "720p60,720p30,160p30,audio_only", false},
+		{VariantConstraints{MaxHeight: 720}, "720p60", "720p30,160p30,chunked,audio_only", false},
+		{VariantConstraints{MaxHeight: 720, PreferHighFrameRate: true}, "720p60", "720p30,160p30,chunked,audio_only", false},
+		{VariantConstraints{MaxBandwidth: 2500000}, "720p30", "160p30,720p60,chunked,audio_only", false},
+		{VariantConstraints{AudioOnly: true}, "audio_only", "160p30,720p30,720p60,chunked", false},
+		{VariantConstraints{MaxHeight: 100}, "160p30", "720p30,720p60,chunked,audio_only", true},
+	}
+
+	for _, c := range cases {
+		selection, err := SelectVariant(variants, c.constraints)
+		if err != nil {
+			t.Fatalf("Unexpected error selecting variant: %s", err.Error())
+		}
+
+		fallbacks := strings.Join(variantNames(selection.Fallbacks), ",")
+		if selection.Selected.Name != c.selected || fallbacks != c.fallbacks ||
+			(len(selection.FallbackReasons) > 0) != c.relaxed {
+			t.Fatalf("Incorrect selection for %+v: selected=%s fallbacks=%s reasons=%v",
+				c.constraints, selection.Selected.Name, fallbacks, selection.FallbackReasons)
+		}
+	}
+}
+
+func TestSelectVariantKeepsPlaylistOrder(t *testing.T) {
+	// A playlist that lists the lower frame rate first
+	variants := []*StreamVariant{
+		{Name: "720p30", Height: 720, FrameRate: 30, Bandwidth: 2000000},
+		{Name: "720p60", Height: 720, FrameRate: 60, Bandwidth: 3000000},
+	}
+
+	cases := []struct {
+		constraints VariantConstraints
+		selected    string
+	}{
+		{VariantConstraints{}, "720p30"},
+		{VariantConstraints{PreferHighFrameRate: true}, "720p60"},
+	}
+
+	for _, c := range cases {
+		selection, err := SelectVariant(variants, c.constraints)
+		if err != nil {
+			t.Fatalf("Unexpected error selecting variant: %s", err.Error())
+		}
+		if selection.Selected.Name != c.selected {
+			t.Fatalf("Incorrect selection for %+v: selected=%s", c.constraints, selection.Selected.Name)
+		}
+	}
+}
+
+func TestSelectVariantFallsBack(t *testing.T) {
+	videoOnly := []*StreamVariant{
+		{Name: "720p30", Height: 720, FrameRate: 30, Bandwidth: 2000000},
+		{Name: "360p30", Height: 360, FrameRate: 30, Bandwidth: 600000},
+	}
+
+	selection, _ := SelectVariant(videoOnly, VariantConstraints{AudioOnly: true})
+	if selection.Selected.Name != "360p30" || len(selection.FallbackReasons) != 1 {
+		t.Fatalf("Expected audio only to fall back to the lowest video: %+v", selection)
+	}
+
+	audioOnly := []*StreamVariant{{Name: "audio_only", AudioOnly: true, Bandwidth: 160000}}
+	selection, _ = SelectVariant(audioOnly, VariantConstraints{MaxHeight: 720})
+	if selection.Selected.Name != "audio_only" || len(selection.FallbackReasons) != 1 {
+		t.Fatalf("Expected video to fall back to audio only: %+v", selection)
+	}
+
+	_, err := SelectVariant(nil, VariantConstraints{})
+	if KindOf(err) != ErrNotFound {
+		t.Fatalf("Expected not found error for an empty playlist, got: %v", err)
+	}
+}